@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"strings"
+
+	"github.com/rustedturnip/go-csgo-item-parser/parser"
 )
 
 // New takes the required languageData and itemData maps (from csgo_english.txt and
@@ -56,7 +58,7 @@ func New(languageData, itemData map[string]interface{}) (*Csgo, error) {
 		return nil, err
 	}
 
-	weaponSets, err := items.getWeaponSets()
+	weaponSets, err := items.getWeaponSets(paintkits)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +82,7 @@ func New(languageData, itemData map[string]interface{}) (*Csgo, error) {
 		return nil, err
 	}
 
-	return &Csgo{
+	response := &Csgo{
 		Rarities:   rarities,
 		Qualities:  qualities,
 		Paintkits:  paintkits,
@@ -90,6 +92,8 @@ func New(languageData, itemData map[string]interface{}) (*Csgo, error) {
 		KnifeSet:   knifeSet,
 		GloveSet:   gloveSet,
 
+		PaintkitFamilies: getPaintkitFamilies(paintkits),
+
 		Stickerkits: stickerEnteties.stickers,
 		Spraykits:   stickerEnteties.sprays,
 		Patchkits:   stickerEnteties.patches,
@@ -103,7 +107,11 @@ func New(languageData, itemData map[string]interface{}) (*Csgo, error) {
 		Tools:           itemEntities.tools,
 		Characters:      itemEntities.characters,
 		Collectables:    itemEntities.collectibles,
-	}, nil
+	}
+
+	response.Reindex()
+
+	return response, nil
 }
 
 // language represents a Csgo language file that provides the descriptions
@@ -121,32 +129,42 @@ func (l *language) lookup(key string) (string, error) {
 	// remove pound sign from beginning of string
 	key = strings.TrimPrefix(key, "#")
 
-	val, err := crawlToType[string](l.data, strings.ToLower(key))
-	if err != nil {
-		return "", fmt.Errorf("could not locate token (%s) in language: %s", key, err.Error())
+	// data is already indexed by lowercased key, so is accessed directly
+	// rather than through a case-insensitive crawl
+	val, ok := l.data[strings.ToLower(key)]
+	if !ok {
+		return "", fmt.Errorf("could not locate token (%s) in language: %s", key, errCrawlNotFound.Error())
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("could not locate token (%s) in language: could not convert value to provided type %T", key, str)
 	}
 
-	return val, nil
+	return str, nil
 }
 
 // newLanguage takes in the data map of an already parsed language file and
 // returns a language "client" that can be used to perform key lookups.
 func newLanguage(data map[string]interface{}) (*language, error) {
 
-	// check language base data exists
-	lang, ok := data["lang"].(map[string]interface{})
-	if !ok {
+	// check language base data exists (keys are matched regardless of case,
+	// e.g. "Tokens" or "tokens")
+	lang, err := crawl[map[string]interface{}](data, "lang")
+	if err != nil {
 		return nil, errors.New("unable to locate \"lang\" in provided languageData")
 	}
 
 	// check lang has tokens as expected
-	tokens, ok := lang["Tokens"].(map[string]interface{})
-	if !ok {
+	tokens, err := crawl[map[string]interface{}](lang, "Tokens")
+	if err != nil {
 		return nil, errors.New("unable to locate \"lang/Tokens\" in provided languageData")
 	}
 
+	// tokens are indexed by lowercased key so that lookups, which are case
+	// insensitive, don't have to scan every token
 	l := &language{
-		data: make(map[string]interface{}),
+		data: make(map[string]interface{}, len(tokens)),
 	}
 
 	for k, v := range tokens {
@@ -213,6 +231,9 @@ type Csgo struct {
 	KnifeSet   map[string][]string   `json:"KnifeSet"`
 	GloveSet   map[string][]string   `json:"GloveSet"`
 
+	// Paintkits grouped by description_tag, e.g. the phases of Doppler
+	PaintkitFamilies map[string]*PaintkitFamily `json:"PaintkitFamilies"`
+
 	// Sticker subtypes
 	Stickerkits map[string]*Stickerkit `json:"Stickerkits"`
 	Spraykits   map[string]*Spraykit   `json:"Spraykits"`
@@ -229,6 +250,9 @@ type Csgo struct {
 	Characters      map[string]*Character      `json:"Characters"`
 	// some might not have descriptions due to them being placeholders
 	Collectables map[string]*Collectible `json:"Collectables"`
+
+	// indexes resolve entities by their index (see Reindex)
+	indexes *csgoIndexes
 }
 
 var (
@@ -263,11 +287,19 @@ func crawlToType[T any](m map[string]interface{}, path ...string) (T, error) {
 // crawl will return the value at the provided key casting it to the provided
 // type (T). If the value doesn't exist or doesn't match the type provided, an
 // error will be returned.
+//
+// As in the engine, keys are matched regardless of case, though an exact
+// match is preferred (and is looked up directly, with the keys of m only
+// compared regardless of case where there isn't one).
 func crawl[T any](m map[string]interface{}, key string) (T, error) {
 
 	var empty T // equivalent of nil
 
 	val, ok := m[key]
+	if !ok {
+		val, ok = parser.LookupFold(m, key)
+	}
+
 	if !ok {
 		return empty, errCrawlNotFound
 	}
@@ -0,0 +1,173 @@
+package csgo
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// phasedPaintkitPrefix is the prefix of the ids of the (anodized)
+	// Doppler and Gamma Doppler Paintkits, which are the only Paintkits
+	// labelled with a phase.
+	//
+	// This is a heuristic, as the items_game file doesn't otherwise identify
+	// the Paintkits with phases; any other Paintkit with the prefix whose id
+	// contains a component of paintkitPhases would also be labelled.
+	phasedPaintkitPrefix = "am_"
+)
+
+var (
+	// paintkitPhases maps the components of Doppler and Gamma Doppler
+	// Paintkit ids that identify the phase (or gem) of the Paintkit to their
+	// labels, e.g. am_doppler_phase2 or am_ruby_marbleized.
+	paintkitPhases = map[string]string{
+		"phase1":     "Phase 1",
+		"phase2":     "Phase 2",
+		"phase3":     "Phase 3",
+		"phase4":     "Phase 4",
+		"ruby":       "Ruby",
+		"sapphire":   "Sapphire",
+		"blackpearl": "Black Pearl",
+		"emerald":    "Emerald",
+	}
+
+	// paintkitGemTagStems maps the tag stems (see getPaintkitTagStem) of the
+	// gems of the Doppler and Gamma Doppler finishes, which unlike the phases
+	// don't share the stem of their finish, to the stem of their finish.
+	paintkitGemTagStems = map[string]string{
+		"am_ruby_marbleized":       "am_doppler",
+		"am_sapphire_marbleized":   "am_doppler",
+		"am_blackpearl_marbleized": "am_doppler",
+		"am_emerald_marbleized":    "am_gamma_doppler",
+	}
+)
+
+// PaintkitFamily represents a group of Paintkits that share a
+// description_tag, such as the phases and gems of the Doppler and Gamma
+// Doppler finishes, which are each a separate Paintkit.
+//
+// The Id of a family is the stem of the description_tag shared by its
+// members (e.g. am_doppler for #PaintKit_am_doppler_phase1_Tag), which unlike
+// its Name is independent of the language.
+type PaintkitFamily struct {
+	Id      string                  `json:"id"`
+	Name    string                  `json:"name"`
+	Members []*PaintkitFamilyMember `json:"members"`
+}
+
+// PaintkitFamilyMember represents a single Paintkit of a PaintkitFamily,
+// along with its phase (e.g. "Phase 2" or "Ruby"), which is only set for the
+// Doppler and Gamma Doppler Paintkits.
+type PaintkitFamilyMember struct {
+	PaintkitId string `json:"paintkitId"`
+	Index      int    `json:"index"`
+	Phase      string `json:"phase"`
+}
+
+// PaintkitFamilyByIndex returns the PaintkitFamily of the Paintkit with the
+// provided paint index, along with the phase of the Paintkit within it.
+func (c *Csgo) PaintkitFamilyByIndex(index int) (*PaintkitFamily, string, bool) {
+
+	entry, ok := c.index().paintkitFamilies[index]
+	if !ok {
+		return nil, "", false
+	}
+
+	return entry.family, entry.member.Phase, true
+}
+
+// getPaintkitFamilies groups the provided Paintkits by the stem of their
+// description_tag, returning the groups of more than one Paintkit as
+// map[familyId]*PaintkitFamily.
+func getPaintkitFamilies(paintkits map[string]*Paintkit) map[string]*PaintkitFamily {
+
+	groups := make(map[string][]*Paintkit)
+
+	for _, paintkit := range paintkits {
+		stem := getPaintkitTagStem(paintkit.descriptionTag)
+		if stem == "" {
+			continue
+		}
+
+		groups[stem] = append(groups[stem], paintkit)
+	}
+
+	response := make(map[string]*PaintkitFamily)
+
+	for stem, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		// members are ordered by paint index
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Index < group[j].Index
+		})
+
+		// the family is named after its first member, as each member's
+		// description_tag resolves to the name of the finish
+		family := &PaintkitFamily{
+			Id:   stem,
+			Name: group[0].Name,
+		}
+
+		for _, paintkit := range group {
+			family.Members = append(family.Members, &PaintkitFamilyMember{
+				PaintkitId: paintkit.Id,
+				Index:      paintkit.Index,
+				Phase:      getPaintkitPhase(paintkit.Id),
+			})
+		}
+
+		response[family.Id] = family
+	}
+
+	return response
+}
+
+// getPaintkitTagStem returns the stem of the provided description_tag, i.e.
+// the tag without its "#PaintKit_" prefix and "_Tag" suffix (lowercased), or
+// an empty string where the tag is empty.
+//
+// The phase of a Doppler or Gamma Doppler Paintkit is removed from the stem
+// (e.g. #PaintKit_am_doppler_phase2_Tag is am_doppler), and the stem of a gem
+// is that of its finish (see paintkitGemTagStems).
+func getPaintkitTagStem(tag string) string {
+
+	stem := strings.ToLower(strings.TrimPrefix(tag, "#"))
+	stem = strings.TrimPrefix(stem, "paintkit_")
+	stem = strings.TrimSuffix(stem, "_tag")
+
+	if !strings.HasPrefix(stem, phasedPaintkitPrefix) {
+		return stem
+	}
+
+	if finish, ok := paintkitGemTagStems[stem]; ok {
+		return finish
+	}
+
+	components := strings.Split(stem, "_")
+
+	if _, ok := paintkitPhases[components[len(components)-1]]; ok && len(components) > 2 {
+		components = components[:len(components)-1]
+	}
+
+	return strings.Join(components, "_")
+}
+
+// getPaintkitPhase returns the phase label of the provided Paintkit id, or
+// an empty string where it isn't a Doppler or Gamma Doppler Paintkit.
+func getPaintkitPhase(paintkitId string) string {
+
+	if !strings.HasPrefix(paintkitId, phasedPaintkitPrefix) {
+		return ""
+	}
+
+	for _, component := range strings.Split(paintkitId, "_") {
+		if phase, ok := paintkitPhases[component]; ok {
+			return phase
+		}
+	}
+
+	return ""
+}
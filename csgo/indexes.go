@@ -0,0 +1,242 @@
+package csgo
+
+import (
+	"sort"
+)
+
+// The items_game.txt file keys most entities by a numeric index (e.g. the
+// paint index of a Paintkit, or the definition index of a Weapon), which is
+// what Steam inventories, inspect links and marketplaces refer to entities
+// by. The following resolve an index to its entity, returning false where no
+// entity has the provided index.
+//
+// The lookups are served from indexes built by New (or, for a Csgo created
+// otherwise, e.g. decoded from json, by the first lookup), which must be
+// rebuilt through Reindex after modifying the Csgo.
+
+// csgoIndexes maps the index of each entity of a Csgo to the entity.
+type csgoIndexes struct {
+	paintkits        map[int]*Paintkit
+	paintkitFamilies map[int]*paintkitFamilyIndex
+	weapons          map[int]*Weapon
+	gloves           map[int]*Gloves
+	equipment        map[int]*Equipment
+	tools            map[int]*Tool
+	weaponCrates     map[int]*WeaponCrate
+	stickerCapsules  map[int]*StickerCapsule
+	characters       map[int]*Character
+	collectibles     map[int]*Collectible
+	stickerkits      map[int]*Stickerkit
+	spraykits        map[int]*Spraykit
+	patchkits        map[int]*Patchkit
+	musickits        map[int]*Musickit
+	keychains        map[int]*Keychain
+	rarities         map[int]*Rarity
+	qualities        map[int]*Quality
+}
+
+// paintkitFamilyIndex is the PaintkitFamily of a Paintkit, along with the
+// Paintkit's membership of it.
+type paintkitFamilyIndex struct {
+	family *PaintkitFamily
+	member *PaintkitFamilyMember
+}
+
+// Reindex rebuilds the indexes used to resolve entities by their index,
+// which must be called after modifying the entities of the Csgo.
+func (c *Csgo) Reindex() {
+
+	indexes := &csgoIndexes{
+		paintkits:        indexBy(c.Paintkits, func(p *Paintkit) int { return p.Index }),
+		paintkitFamilies: make(map[int]*paintkitFamilyIndex),
+		weapons:          indexBy(c.Guns, func(w *Weapon) int { return w.Index }),
+		gloves:           indexBy(c.Gloves, func(g *Gloves) int { return g.Index }),
+		equipment:        indexBy(c.Equipment, func(e *Equipment) int { return e.Index }),
+		tools:            indexBy(c.Tools, func(t *Tool) int { return t.Index }),
+		weaponCrates:     indexBy(c.WeaponCrates, func(w *WeaponCrate) int { return w.Index }),
+		stickerCapsules:  indexBy(c.StickerCapsules, func(s *StickerCapsule) int { return s.Index }),
+		characters:       indexBy(c.Characters, func(ch *Character) int { return ch.Index }),
+		collectibles:     indexBy(c.Collectables, func(co *Collectible) int { return co.Index }),
+		stickerkits:      indexBy(c.Stickerkits, func(s *Stickerkit) int { return s.Index }),
+		spraykits:        indexBy(c.Spraykits, func(s *Spraykit) int { return s.Index }),
+		patchkits:        indexBy(c.Patchkits, func(p *Patchkit) int { return p.Index }),
+		musickits:        indexBy(c.Musickits, func(m *Musickit) int { return m.Index }),
+		keychains:        indexBy(c.Keychains, func(k *Keychain) int { return k.Index }),
+		rarities:         indexBy(c.Rarities, func(r *Rarity) int { return r.Index }),
+		qualities:        indexBy(c.Qualities, func(q *Quality) int { return q.Index }),
+	}
+
+	// guns take precedence over knives sharing an index
+	for index, knife := range indexBy(c.Knives, func(w *Weapon) int { return w.Index }) {
+		if _, ok := indexes.weapons[index]; !ok {
+			indexes.weapons[index] = knife
+		}
+	}
+
+	// families are visited in order of id, so that a Paintkit within more
+	// than one family is resolved to the same family each time
+	for _, id := range sortedKeys(c.PaintkitFamilies) {
+		family := c.PaintkitFamilies[id]
+
+		for _, member := range family.Members {
+			if _, ok := indexes.paintkitFamilies[member.Index]; ok {
+				continue
+			}
+
+			indexes.paintkitFamilies[member.Index] = &paintkitFamilyIndex{
+				family: family,
+				member: member,
+			}
+		}
+	}
+
+	c.indexes = indexes
+}
+
+// index returns the indexes of the Csgo, building them where they haven't
+// yet been.
+func (c *Csgo) index() *csgoIndexes {
+
+	if c.indexes == nil {
+		c.Reindex()
+	}
+
+	return c.indexes
+}
+
+// PaintkitByIndex returns the Paintkit with the provided paint index.
+func (c *Csgo) PaintkitByIndex(index int) (*Paintkit, bool) {
+	paintkit, ok := c.index().paintkits[index]
+	return paintkit, ok
+}
+
+// WeaponByIndex returns the Weapon (gun or knife) with the provided
+// definition index.
+func (c *Csgo) WeaponByIndex(index int) (*Weapon, bool) {
+	weapon, ok := c.index().weapons[index]
+	return weapon, ok
+}
+
+// GlovesByIndex returns the Gloves with the provided definition index.
+func (c *Csgo) GlovesByIndex(index int) (*Gloves, bool) {
+	gloves, ok := c.index().gloves[index]
+	return gloves, ok
+}
+
+// EquipmentByIndex returns the Equipment with the provided definition index.
+func (c *Csgo) EquipmentByIndex(index int) (*Equipment, bool) {
+	equipment, ok := c.index().equipment[index]
+	return equipment, ok
+}
+
+// ToolByIndex returns the Tool with the provided definition index.
+func (c *Csgo) ToolByIndex(index int) (*Tool, bool) {
+	tool, ok := c.index().tools[index]
+	return tool, ok
+}
+
+// WeaponCrateByIndex returns the WeaponCrate with the provided definition
+// index.
+func (c *Csgo) WeaponCrateByIndex(index int) (*WeaponCrate, bool) {
+	crate, ok := c.index().weaponCrates[index]
+	return crate, ok
+}
+
+// StickerCapsuleByIndex returns the StickerCapsule with the provided
+// definition index.
+func (c *Csgo) StickerCapsuleByIndex(index int) (*StickerCapsule, bool) {
+	capsule, ok := c.index().stickerCapsules[index]
+	return capsule, ok
+}
+
+// CharacterByIndex returns the Character with the provided definition index.
+func (c *Csgo) CharacterByIndex(index int) (*Character, bool) {
+	character, ok := c.index().characters[index]
+	return character, ok
+}
+
+// CollectibleByIndex returns the Collectible with the provided definition
+// index.
+func (c *Csgo) CollectibleByIndex(index int) (*Collectible, bool) {
+	collectible, ok := c.index().collectibles[index]
+	return collectible, ok
+}
+
+// StickerkitByIndex returns the Stickerkit with the provided index.
+func (c *Csgo) StickerkitByIndex(index int) (*Stickerkit, bool) {
+	stickerkit, ok := c.index().stickerkits[index]
+	return stickerkit, ok
+}
+
+// SpraykitByIndex returns the Spraykit with the provided (sticker kit)
+// index.
+func (c *Csgo) SpraykitByIndex(index int) (*Spraykit, bool) {
+	spraykit, ok := c.index().spraykits[index]
+	return spraykit, ok
+}
+
+// PatchkitByIndex returns the Patchkit with the provided (sticker kit)
+// index.
+func (c *Csgo) PatchkitByIndex(index int) (*Patchkit, bool) {
+	patchkit, ok := c.index().patchkits[index]
+	return patchkit, ok
+}
+
+// MusickitByIndex returns the Musickit with the provided index.
+func (c *Csgo) MusickitByIndex(index int) (*Musickit, bool) {
+	musickit, ok := c.index().musickits[index]
+	return musickit, ok
+}
+
+// KeychainByIndex returns the Keychain with the provided index.
+func (c *Csgo) KeychainByIndex(index int) (*Keychain, bool) {
+	keychain, ok := c.index().keychains[index]
+	return keychain, ok
+}
+
+// RarityByIndex returns the Rarity with the provided index (value).
+func (c *Csgo) RarityByIndex(index int) (*Rarity, bool) {
+	rarity, ok := c.index().rarities[index]
+	return rarity, ok
+}
+
+// QualityByIndex returns the Quality with the provided index (value).
+func (c *Csgo) QualityByIndex(index int) (*Quality, bool) {
+	quality, ok := c.index().qualities[index]
+	return quality, ok
+}
+
+// indexBy returns the entities of m keyed by their index (as returned by
+// indexOf).
+//
+// Where more than one entity shares an index, the entity with the lowest id
+// (key of m) is used, so that the same entity is resolved each time.
+func indexBy[T any](m map[string]*T, indexOf func(*T) int) map[int]*T {
+
+	response := make(map[int]*T, len(m))
+
+	for _, id := range sortedKeys(m) {
+		index := indexOf(m[id])
+
+		if _, ok := response[index]; ok {
+			continue
+		}
+
+		response[index] = m[id]
+	}
+
+	return response
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[T any](m map[string]T) []string {
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -2,6 +2,7 @@ package csgo
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -35,14 +36,12 @@ const (
 	clientLootListItemTypeUnknown clientLootListItemType = iota
 	clientLootListItemTypeSubList
 	clientLootListItemTypeSticker
+	clientLootListItemTypeSkin
 )
 
-// clientLootListItems represents an item list for items within a client_loot_list,
-// along with the items' type.
-type clientLootListItems struct {
-	listType clientLootListItemType
-	items    []string
-}
+// clientLootListItems represents the items within a client_loot_list, grouped
+// by the items' type (as a list can contain items of more than one type).
+type clientLootListItems map[clientLootListItemType][]string
 
 // clientLootList represents a flattened client_loot_list structure from the items_game
 // file. The root of each client_loot_list object is available from the
@@ -50,7 +49,7 @@ type clientLootListItems struct {
 // do not retain any subgroups of the client_loot_list.
 type clientLootList struct {
 	id        string
-	listItems *clientLootListItems
+	listItems clientLootListItems
 }
 
 // getClientLootLists retrieves all the client_loot_lists from the c.items
@@ -74,38 +73,52 @@ func (c *csgoItems) getClientLootLists() (map[string]*clientLootList, error) {
 
 		entry := &clientLootList{
 			id:        id,
-			listItems: &clientLootListItems{},
+			listItems: make(clientLootListItems),
 		}
 
+		// each item is classified separately
 		for itemName, _ := range listMap {
 
-			// if list contains sublist
+			// if item is a sublist
 			if _, ok := lootLists[itemName]; ok {
-				entry.listItems.listType = clientLootListItemTypeSubList
-				entry.listItems.items = append(entry.listItems.items, itemName)
+				entry.listItems[clientLootListItemTypeSubList] = append(entry.listItems[clientLootListItemTypeSubList], itemName)
 				continue
 			}
 
-			// if list contains stickers
+			// if item is a sticker
 			if strings.HasSuffix(itemName, "]sticker") {
-				entry.listItems.listType = clientLootListItemTypeSticker
 				itemName = strings.TrimPrefix(itemName, "[")
 				itemName = strings.TrimSuffix(itemName, "]sticker")
-				entry.listItems.items = append(entry.listItems.items, itemName)
+				entry.listItems[clientLootListItemTypeSticker] = append(entry.listItems[clientLootListItemTypeSticker], itemName)
+				continue
+			}
+
+			// if item is a skin ("[paintkitId]weaponId")
+			if _, _, err := splitItemPaintkitString(itemName); err == nil {
+				entry.listItems[clientLootListItemTypeSkin] = append(entry.listItems[clientLootListItemTypeSkin], itemName)
 				continue
 			}
 
 			continue
 		}
 
+		// items are ordered by id, rather than the random order of listMap
+		for _, items := range entry.listItems {
+			sort.Strings(items)
+		}
+
 		response[entry.id] = entry
 	}
 
 	return response, nil
 }
 
-// crawlClientLootLists will recursively traverse down through the lists that have
-// clientLootListItemTypeSubList as the type to identify the root type of the list.
+// crawlClientLootLists will recursively traverse down through the sublists of
+// the list to identify the root type of the list, along with its items.
+//
+// Where the items of the list (including those of its sublists) are of more
+// than one type, the list's type is unknown. Sublists of an unknown type are
+// ignored.
 func crawlClientLootLists(listId string, clientLootLists map[string]*clientLootList) (clientLootListItemType, []string) {
 
 	list, ok := clientLootLists[listId]
@@ -113,24 +126,135 @@ func crawlClientLootLists(listId string, clientLootLists map[string]*clientLootL
 		return clientLootListItemTypeUnknown, nil
 	}
 
-	switch list.listItems.listType {
-
-	case clientLootListItemTypeSticker:
-		return clientLootListItemTypeSticker, list.listItems.items
+	found := make(clientLootListItems)
 
-	case clientLootListItemTypeSubList:
-		responseType := clientLootListItemTypeUnknown
-		responseItems := make([]string, 0)
+	for itemType, items := range list.listItems {
+		if itemType != clientLootListItemTypeSubList {
+			found[itemType] = append(found[itemType], items...)
+		}
+	}
 
-		for _, sublist := range list.listItems.items {
-			subType, items := crawlClientLootLists(sublist, clientLootLists)
-			responseType = subType
-			responseItems = append(responseItems, items...)
+	for _, sublist := range list.listItems[clientLootListItemTypeSubList] {
+		subType, items := crawlClientLootLists(sublist, clientLootLists)
+		if subType == clientLootListItemTypeUnknown {
+			continue
 		}
 
-		return responseType, responseItems
+		found[subType] = append(found[subType], items...)
+	}
+
+	if len(found) != 1 {
+		return clientLootListItemTypeUnknown, nil
+	}
 
+	for itemType, items := range found {
+		return itemType, items
 	}
 
 	return clientLootListItemTypeUnknown, nil
 }
+
+// getSetLootLists retrieves the client_loot_list ids linked to each item_set
+// through the crates (and souvenir packages) of the set, i.e. the items
+// tagged with the set (tags > ItemSet > tag_value) along with either a
+// revolving_loot_list (set supply crate series) or a loot_list_name, and
+// returns them as map[setId][]clientLootListId.
+func (c *csgoItems) getSetLootLists() (map[string][]string, error) {
+
+	response := make(map[string][]string)
+
+	items, err := crawlToType[map[string]interface{}](c.items, "items")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		setId, err := crawlToType[string](itemMap, "tags", "ItemSet", "tag_value")
+		if err != nil {
+			continue
+		}
+
+		if val, err := crawlToType[string](itemMap, "attributes", "set supply crate series", "value"); err == nil {
+			if listId, ok := c.revolvingLootLists[val]; ok {
+				response[setId] = append(response[setId], listId)
+			}
+		}
+
+		if listId, ok := itemMap["loot_list_name"].(string); ok {
+			response[setId] = append(response[setId], listId)
+		}
+	}
+
+	for setId := range response {
+		sort.Strings(response[setId])
+	}
+
+	return response, nil
+}
+
+// getSetSkinRarities returns the rarity of each skin ("[paintkitId]weaponId")
+// of the provided item_set, from the client_loot_lists of the set, i.e. those
+// with the id of the set followed by a rarity (e.g. set_dust_2_rare), along
+// with the lists linked to the set (see getSetLootLists).
+func (c *csgoItems) getSetSkinRarities(setId string, linkedLists []string, rarityIds []string) map[string]string {
+
+	response := make(map[string]string)
+	visited := make(map[string]bool)
+
+	crawlSkinRarities(setId, "", rarityIds, c.clientLootLists, visited, response)
+
+	for _, rarityId := range rarityIds {
+		crawlSkinRarities(setId+"_"+rarityId, rarityId, rarityIds, c.clientLootLists, visited, response)
+	}
+
+	for _, listId := range linkedLists {
+		crawlSkinRarities(listId, "", rarityIds, c.clientLootLists, visited, response)
+	}
+
+	return response
+}
+
+// crawlSkinRarities will recursively traverse down through the provided
+// list and its sublists, adding the rarity of each skin found to rarities
+// (where not already present). The rarity of a list is identified by the
+// suffix of its id, otherwise it is that of its parent (rarity).
+func crawlSkinRarities(listId, rarity string, rarityIds []string, clientLootLists map[string]*clientLootList, visited map[string]bool, rarities map[string]string) {
+
+	list, ok := clientLootLists[listId]
+	if !ok || visited[listId] {
+		return
+	}
+
+	visited[listId] = true
+
+	// the longest matching suffix is used, in case one rarity id is the
+	// suffix of another
+	suffix := ""
+	for _, rarityId := range rarityIds {
+		if strings.HasSuffix(listId, "_"+rarityId) && len(rarityId) > len(suffix) {
+			suffix = rarityId
+		}
+	}
+
+	if suffix != "" {
+		rarity = suffix
+	}
+
+	if rarity != "" {
+		for _, skin := range list.listItems[clientLootListItemTypeSkin] {
+			if _, ok := rarities[skin]; !ok {
+				rarities[skin] = rarity
+			}
+		}
+	}
+
+	for _, sublist := range list.listItems[clientLootListItemTypeSubList] {
+		crawlSkinRarities(sublist, rarity, rarityIds, clientLootLists, visited, rarities)
+	}
+}
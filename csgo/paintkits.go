@@ -2,6 +2,8 @@ package csgo
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
@@ -12,22 +14,281 @@ var (
 	defaultMaxFloat = decimal.RequireFromString("0.8")
 )
 
+// PaintStyle represents the finish style of a Paintkit (its "style" code),
+// which determines how the pattern, colors and other attributes of the
+// Paintkit are applied to a weapon.
+type PaintStyle int
+
+const (
+	PaintStyleUnknown PaintStyle = iota
+	PaintStyleSolidColor
+	PaintStyleHydrographic
+	PaintStyleSprayPaint
+	PaintStyleAnodized
+	PaintStyleAnodizedMulticolored
+	PaintStyleAnodizedAirbrushed
+	PaintStyleCustomPaintJob
+	PaintStylePatina
+	PaintStyleGunsmith
+)
+
+var (
+	// paintStyleNames maps each known PaintStyle to its name, as displayed by
+	// the workshop.
+	paintStyleNames = map[PaintStyle]string{
+		PaintStyleSolidColor:           "Solid Color",
+		PaintStyleHydrographic:         "Hydrographic",
+		PaintStyleSprayPaint:           "Spray-Paint",
+		PaintStyleAnodized:             "Anodized",
+		PaintStyleAnodizedMulticolored: "Anodized Multicolored",
+		PaintStyleAnodizedAirbrushed:   "Anodized Airbrushed",
+		PaintStyleCustomPaintJob:       "Custom Paint Job",
+		PaintStylePatina:               "Patina",
+		PaintStyleGunsmith:             "Gunsmith",
+	}
+)
+
+// String returns the name of the PaintStyle (e.g. "Custom Paint Job"), or
+// its code where it isn't known.
+func (p PaintStyle) String() string {
+
+	if name, ok := paintStyleNames[p]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("PaintStyle(%d)", int(p))
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the PaintStyle as
+// its name, its code where it isn't known, or an empty string where unset.
+func (p PaintStyle) MarshalText() ([]byte, error) {
+
+	if p == PaintStyleUnknown {
+		return []byte{}, nil
+	}
+
+	if name, ok := paintStyleNames[p]; ok {
+		return []byte(name), nil
+	}
+
+	return []byte(strconv.Itoa(int(p))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting either the
+// name of a PaintStyle (regardless of case) or its code.
+func (p *PaintStyle) UnmarshalText(text []byte) error {
+
+	if len(text) == 0 {
+		*p = PaintStyleUnknown
+		return nil
+	}
+
+	for style, name := range paintStyleNames {
+		if strings.EqualFold(name, string(text)) {
+			*p = style
+			return nil
+		}
+	}
+
+	code, err := strconv.Atoi(string(text))
+	if err != nil {
+		return fmt.Errorf("unknown PaintStyle (%s)", text)
+	}
+
+	*p = PaintStyle(code)
+
+	return nil
+}
+
+// Color represents an RGB color of a Paintkit.
+type Color struct {
+	R uint8 `json:"r"`
+	G uint8 `json:"g"`
+	B uint8 `json:"b"`
+}
+
+// Hex returns the Color in the format "#rrggbb".
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// parseColor parses a color in the format used by items_game, i.e. "R G B".
+func parseColor(val string) (*Color, error) {
+
+	components := strings.Fields(val)
+	if len(components) != 3 {
+		return nil, fmt.Errorf("unexpected color format (%s)", val)
+	}
+
+	var rgb [3]uint8
+
+	for i, component := range components {
+		c, err := strconv.ParseUint(component, 10, 8)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("unexpected color format (%s)", val))
+		}
+
+		rgb[i] = uint8(c)
+	}
+
+	return &Color{R: rgb[0], G: rgb[1], B: rgb[2]}, nil
+}
+
+// Range represents the range of values an attribute of a Paintkit can take,
+// which is chosen from within the range by the pattern seed of each skin.
+type Range struct {
+	Start decimal.Decimal `json:"start"`
+	End   decimal.Decimal `json:"end"`
+}
+
 // Paintkit represents the image details of a skin, i.e. the available float
 // range the skin can be in. Every entities.Skin has an associated Paintkit.
+//
+// Attributes not set by the Paintkit within the items_game file are left as
+// their zero value.
 type Paintkit struct {
 	Id          string          `json:"id"`
+	Index       int             `json:"index"`
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
 	RarityId    string          `json:"rarityId"`
 	MinFloat    decimal.Decimal `json:"minFloat"`
 	MaxFloat    decimal.Decimal `json:"maxFloat"`
+
+	// finish
+	Style             PaintStyle      `json:"style"`
+	Pattern           string          `json:"pattern"`
+	CompositeMaterial string          `json:"compositeMaterial"`
+	Colors            [4]*Color       `json:"colors"`
+	Pearlescent       decimal.Decimal `json:"pearlescent"`
+	UseNormal         bool            `json:"useNormal"`
+	NormalMap         string          `json:"normalMap"`
+	OnlyFirstMaterial bool            `json:"onlyFirstMaterial"`
+	LegacyModel       bool            `json:"legacyModel"`
+
+	// lighting
+	PhongExponent    int             `json:"phongExponent"`
+	PhongIntensity   int             `json:"phongIntensity"`
+	PhongAlbedoBoost decimal.Decimal `json:"phongAlbedoBoost"`
+
+	// pattern placement, chosen within each range by the pattern seed
+	PatternScale                  decimal.Decimal `json:"patternScale"`
+	PatternOffsetX                Range           `json:"patternOffsetX"`
+	PatternOffsetY                Range           `json:"patternOffsetY"`
+	PatternRotate                 Range           `json:"patternRotate"`
+	IgnoreWeaponSizeScale         bool            `json:"ignoreWeaponSizeScale"`
+	ViewModelExponentOverrideSize int             `json:"viewModelExponentOverrideSize"`
+
+	// descriptionTag is the (unresolved) description_tag of the Paintkit,
+	// which PaintkitFamilies are grouped by
+	descriptionTag string
+}
+
+// paintkitAttribute is the conversion of a single attribute of a Paintkit
+// from its value within the items_game file.
+type paintkitAttribute struct {
+	key string
+	set func(p *Paintkit, val string) error
+}
+
+var (
+	// paintkitAttributes lists the conversions of the (optional) attributes
+	// of a Paintkit, keyed by their field within the items_game file.
+	paintkitAttributes = []paintkitAttribute{
+		{"style", intAttribute(func(p *Paintkit, v int) { p.Style = PaintStyle(v) })},
+		{"pattern", func(p *Paintkit, val string) error { p.Pattern = val; return nil }},
+		{"composite_material_path", func(p *Paintkit, val string) error { p.CompositeMaterial = val; return nil }},
+		{"color0", colorAttribute(0)},
+		{"color1", colorAttribute(1)},
+		{"color2", colorAttribute(2)},
+		{"color3", colorAttribute(3)},
+		{"pearlescent", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.Pearlescent })},
+		{"use_normal", boolAttribute(func(p *Paintkit) *bool { return &p.UseNormal })},
+		{"normal", func(p *Paintkit, val string) error { p.NormalMap = val; return nil }},
+		{"only_first_material", boolAttribute(func(p *Paintkit) *bool { return &p.OnlyFirstMaterial })},
+		{"use_legacy_model", boolAttribute(func(p *Paintkit) *bool { return &p.LegacyModel })},
+		{"phongexponent", intAttribute(func(p *Paintkit, v int) { p.PhongExponent = v })},
+		{"phongintensity", intAttribute(func(p *Paintkit, v int) { p.PhongIntensity = v })},
+		{"phongalbedoboost", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PhongAlbedoBoost })},
+		{"pattern_scale", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PatternScale })},
+		{"pattern_offset_x_start", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PatternOffsetX.Start })},
+		{"pattern_offset_x_end", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PatternOffsetX.End })},
+		{"pattern_offset_y_start", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PatternOffsetY.Start })},
+		{"pattern_offset_y_end", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PatternOffsetY.End })},
+		{"pattern_rotate_start", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PatternRotate.Start })},
+		{"pattern_rotate_end", decimalAttribute(func(p *Paintkit) *decimal.Decimal { return &p.PatternRotate.End })},
+		{"ignore_weapon_size_scale", boolAttribute(func(p *Paintkit) *bool { return &p.IgnoreWeaponSizeScale })},
+		{"view_model_exponent_override_size", intAttribute(func(p *Paintkit, v int) { p.ViewModelExponentOverrideSize = v })},
+	}
+)
+
+// intAttribute returns the conversion of an integer attribute.
+func intAttribute(set func(p *Paintkit, v int)) func(*Paintkit, string) error {
+	return func(p *Paintkit, val string) error {
+
+		v, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
+
+		set(p, v)
+
+		return nil
+	}
+}
+
+// decimalAttribute returns the conversion of a decimal attribute.
+func decimalAttribute(field func(p *Paintkit) *decimal.Decimal) func(*Paintkit, string) error {
+	return func(p *Paintkit, val string) error {
+
+		v, err := decimal.NewFromString(val)
+		if err != nil {
+			return err
+		}
+
+		*field(p) = v
+
+		return nil
+	}
+}
+
+// boolAttribute returns the conversion of a boolean ("0" or "1") attribute.
+func boolAttribute(field func(p *Paintkit) *bool) func(*Paintkit, string) error {
+	return func(p *Paintkit, val string) error {
+
+		v, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+
+		*field(p) = v
+
+		return nil
+	}
+}
+
+// colorAttribute returns the conversion of the color attribute with the
+// provided index.
+func colorAttribute(index int) func(*Paintkit, string) error {
+	return func(p *Paintkit, val string) error {
+
+		c, err := parseColor(val)
+		if err != nil {
+			return err
+		}
+
+		p.Colors[index] = c
+
+		return nil
+	}
 }
 
 // mapToPaintkit converts the provided map into a Paintkit providing
 // all required parameters are present and of the correct type.
-func mapToPaintkit(data map[string]interface{}, language *language) (*Paintkit, error) {
+func mapToPaintkit(index int, data map[string]interface{}, language *language) (*Paintkit, error) {
 
 	response := &Paintkit{
+		Index:    index,
 		RarityId: "common", // common is "default" rarity
 		MinFloat: defaultMinFloat,
 		MaxFloat: defaultMaxFloat,
@@ -48,6 +309,7 @@ func mapToPaintkit(data map[string]interface{}, language *language) (*Paintkit,
 		}
 
 		response.Name = name
+		response.descriptionTag = val
 	}
 
 	// get language Description Id
@@ -78,6 +340,18 @@ func mapToPaintkit(data map[string]interface{}, language *language) (*Paintkit,
 		}
 	}
 
+	// get remaining attributes
+	for _, attribute := range paintkitAttributes {
+		val, err := crawl[string](data, attribute.key)
+		if err != nil {
+			continue
+		}
+
+		if err := attribute.set(response, strings.TrimSpace(val)); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Paintkit (%s) has unexpected %s value (%s)", response.Id, attribute.key, val))
+		}
+	}
+
 	return response, nil
 }
 
@@ -98,12 +372,18 @@ func (c *csgoItems) getPaintkits() (map[string]*Paintkit, error) {
 	}
 
 	for index, kit := range kits {
+
+		iIndex, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("unable to interpret Paintkit index (%s) as int", index))
+		}
+
 		mKit, ok := kit.(map[string]interface{})
 		if !ok {
 			return nil, fmt.Errorf("unexpected Paintkit layout in paint_kits for index (%s)", index)
 		}
 
-		converted, err := mapToPaintkit(mKit, c.language)
+		converted, err := mapToPaintkit(iIndex, mKit, c.language)
 		if err != nil {
 			return nil, err
 		}
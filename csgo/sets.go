@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -19,6 +20,36 @@ type WeaponSet struct {
 	Name        string              `json:"name"`
 	Description string              `json:"description"`
 	Items       map[string][]string `json:"items"`
+
+	// Entries holds each Weapon id - Paintkit id combination of Items, along
+	// with the rarity it drops at from the set
+	Entries []*WeaponSetEntry `json:"entries"`
+}
+
+// WeaponSetEntry represents a single skin (Weapon and Paintkit combination)
+// of a WeaponSet.
+//
+// The rarity of a skin is defined per collection by the client_loot_lists
+// (e.g. set_dust_2_rare), and can differ from the rarity of its Paintkit, or
+// between Weapons sharing the Paintkit. Where the skin isn't found within any
+// loot list, the rarity of its Paintkit is used.
+type WeaponSetEntry struct {
+	WeaponId   string `json:"weaponId"`
+	PaintkitId string `json:"paintkitId"`
+	RarityId   string `json:"rarityId"`
+}
+
+// Rarity returns the rarity of the provided Weapon id - Paintkit id
+// combination within the WeaponSet.
+func (w *WeaponSet) Rarity(weaponId, paintkitId string) (string, bool) {
+
+	for _, entry := range w.Entries {
+		if entry.WeaponId == weaponId && entry.PaintkitId == paintkitId {
+			return entry.RarityId, true
+		}
+	}
+
+	return "", false
 }
 
 // mapToWeaponSet converts the provided map into a WeaponSet providing
@@ -72,8 +103,20 @@ func mapToWeaponSet(id string, data map[string]interface{}, language *language)
 		}
 
 		response.Items[paintkitId] = append(response.Items[paintkitId], itemId)
+		response.Entries = append(response.Entries, &WeaponSetEntry{
+			WeaponId:   itemId,
+			PaintkitId: paintkitId,
+		})
 	}
 
+	sort.Slice(response.Entries, func(i, j int) bool {
+		if response.Entries[i].PaintkitId != response.Entries[j].PaintkitId {
+			return response.Entries[i].PaintkitId < response.Entries[j].PaintkitId
+		}
+
+		return response.Entries[i].WeaponId < response.Entries[j].WeaponId
+	})
+
 	// if set doesn't contain any weapons, return nothing
 	if len(items) == 0 {
 		return nil, nil
@@ -97,15 +140,43 @@ func splitItemPaintkitString(itemPaintkit string) (string, string, error) {
 	return match[2], match[1], nil
 }
 
+// formatItemPaintkitString is the inverse of splitItemPaintkitString,
+// producing the item string ("[paintkitId]itemId") of the provided item ID
+// and Paintkit ID.
+func formatItemPaintkitString(itemId, paintkitId string) string {
+	return "[" + paintkitId + "]" + itemId
+}
+
 // getWeaponSets will process all collections included in the provided items data
 // (derived from items_game) and return them as a map[collectionId]*WeaponSet.
-func (c *csgoItems) getWeaponSets() (map[string]*WeaponSet, error) {
+//
+// The rarity of each entry is resolved from the client_loot_lists of the set
+// (and those of the crates linked to it), falling back to the rarity of the
+// provided paintkits (paint_kits_rarity).
+func (c *csgoItems) getWeaponSets(paintkits map[string]*Paintkit) (map[string]*WeaponSet, error) {
 
 	collections, err := crawlToType[map[string]interface{}](c.items, "item_sets")
 	if err != nil {
 		return nil, errors.Wrap(err, "item_sets missing from item data")
 	}
 
+	rarities, err := crawlToType[map[string]interface{}](c.items, "rarities")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to locate rarities amongst items")
+	}
+
+	rarityIds := make([]string, 0, len(rarities))
+	for rarityId := range rarities {
+		rarityIds = append(rarityIds, rarityId)
+	}
+
+	sort.Strings(rarityIds)
+
+	setLootLists, err := c.getSetLootLists()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to link item_sets to client_loot_lists")
+	}
+
 	response := make(map[string]*WeaponSet)
 
 	for setId, set := range collections {
@@ -129,6 +200,19 @@ func (c *csgoItems) getWeaponSets() (map[string]*WeaponSet, error) {
 			continue
 		}
 
+		skinRarities := c.getSetSkinRarities(setObj.Id, setLootLists[setObj.Id], rarityIds)
+
+		for _, entry := range setObj.Entries {
+			if rarity, ok := skinRarities[formatItemPaintkitString(entry.WeaponId, entry.PaintkitId)]; ok {
+				entry.RarityId = rarity
+				continue
+			}
+
+			if paintkit, ok := paintkits[entry.PaintkitId]; ok {
+				entry.RarityId = paintkit.RarityId
+			}
+		}
+
 		response[setObj.Id] = setObj
 	}
 
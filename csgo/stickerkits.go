@@ -253,7 +253,7 @@ func (c *csgoItems) getStickerEnteties() (*stickerSubtypeContainer, error) {
 
 		iIndex, err := strconv.Atoi(index)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("unable to interpret Stickerkit index (%s) as int", iIndex))
+			return nil, errors.Wrap(err, fmt.Sprintf("unable to interpret Stickerkit index (%s) as int", index))
 		}
 
 		mKit, ok := kit.(map[string]interface{})
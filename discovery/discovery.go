@@ -0,0 +1,399 @@
+// Package discovery locates the CS2 install within a Steam installation
+// (from its libraryfolders.vdf and the game's appmanifest_730.acf), along with
+// the items_game.txt and csgo_<language>.txt files of the game.
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rustedturnip/go-csgo-item-parser/parser"
+	"github.com/rustedturnip/go-csgo-item-parser/vpk"
+)
+
+const (
+	// AppID is the Steam app ID of CS2 (and formerly CSGO).
+	AppID = "730"
+
+	// gameDir is the directory of the game's files within the install
+	gameDir = "game/csgo"
+
+	// itemsGamePath is the path of items_game.txt, relative to gameDir when
+	// loose, or within the package otherwise
+	itemsGamePath = "scripts/items/items_game.txt"
+
+	// packagePath is the path of the game's package, relative to gameDir
+	packagePath = "pak01_dir.vpk"
+
+	// languageDir is the directory of the csgo_<language>.txt files,
+	// relative to gameDir
+	languageDir = "resource"
+)
+
+var (
+	// ErrNotFound is returned (wrapped) where no Steam installation, or no
+	// install of the game within it, can be found.
+	ErrNotFound = errors.New("discovery: not found")
+)
+
+// Location is the location of a game file, which is either a loose file or a
+// file within a VPK package.
+type Location struct {
+
+	// VPK is the path of the package's directory file (pak01_dir.vpk), or
+	// empty where the file is loose.
+	VPK string
+
+	// Path is the path of the file, within VPK where set.
+	Path string
+}
+
+// String returns a description of the Location.
+func (l Location) String() string {
+
+	if l.VPK == "" {
+		return l.Path
+	}
+
+	return l.VPK + ":" + l.Path
+}
+
+// Parse parses the file at the Location (as parser.Parse).
+func (l Location) Parse() (map[string]interface{}, error) {
+
+	if l.VPK == "" {
+		return parser.Parse(l.Path)
+	}
+
+	archive, err := vpk.Open(l.VPK)
+	if err != nil {
+		return nil, err
+	}
+
+	defer archive.Close()
+
+	return parser.ParseFS(archive, l.Path)
+}
+
+// Install is an install of the game.
+type Install struct {
+
+	// SteamRoot is the Steam installation the game was found through, and
+	// Library the library folder holding the game.
+	SteamRoot string
+	Library   string
+
+	// Path is the install directory of the game, i.e.
+	// <Library>/steamapps/common/<installdir>.
+	Path string
+
+	// BuildID is the build of the game installed, as recorded by its
+	// manifest.
+	BuildID string
+
+	// ItemsGame is the location of items_game.txt, which is loose where it
+	// exists as such, or within the game's pak01_dir.vpk otherwise.
+	ItemsGame Location
+
+	// Languages maps each language (e.g. "english") to the location of its
+	// csgo_<language>.txt file.
+	Languages map[string]Location
+}
+
+// Language returns the location of the csgo_<language>.txt file of the
+// provided language, matched regardless of case.
+func (i *Install) Language(language string) (Location, bool) {
+	location, ok := i.Languages[strings.ToLower(language)]
+	return location, ok
+}
+
+// SteamRoots returns the standard locations of a Steam installation on Linux
+// (native, and via Flatpak), whether or not they exist.
+func SteamRoots() []string {
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	return []string{
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	}
+}
+
+// Find locates the game within the Steam installation at steamRoot, or where
+// steamRoot is empty, within the first of SteamRoots holding it.
+func Find(steamRoot string) (*Install, error) {
+
+	if steamRoot != "" {
+		return findIn(steamRoot)
+	}
+
+	for _, root := range SteamRoots() {
+		install, err := findIn(root)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+
+		return install, err
+	}
+
+	return nil, fmt.Errorf("%w: no Steam installation holding app %s in %s", ErrNotFound, AppID, strings.Join(SteamRoots(), ", "))
+}
+
+// findIn locates the game within the Steam installation at steamRoot.
+func findIn(steamRoot string) (*Install, error) {
+
+	if _, err := os.Stat(filepath.Join(steamRoot, "steamapps")); err != nil {
+		return nil, fmt.Errorf("%w: no Steam installation at %s", ErrNotFound, steamRoot)
+	}
+
+	libraries, err := Libraries(steamRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, library := range libraries {
+		manifestPath := filepath.Join(library, "steamapps", "appmanifest_"+AppID+".acf")
+
+		if _, err := os.Stat(manifestPath); err != nil {
+			continue
+		}
+
+		install, err := newInstall(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+
+		install.SteamRoot = steamRoot
+		install.Library = library
+
+		return install, nil
+	}
+
+	return nil, fmt.Errorf("%w: app %s isn't installed within any library of %s", ErrNotFound, AppID, steamRoot)
+}
+
+// Libraries returns the library folders of the Steam installation at
+// steamRoot, as listed by its steamapps/libraryfolders.vdf, beginning with
+// steamRoot itself.
+//
+// Both the current format, in which each library is a section holding its
+// "path", and the former format, in which each library is a value holding
+// its path, are supported.
+func Libraries(steamRoot string) ([]string, error) {
+
+	libraries := []string{steamRoot}
+
+	data, err := parser.Parse(filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return libraries, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	folders, ok := parser.LookupFold(data, "libraryfolders")
+	if !ok {
+		return libraries, nil
+	}
+
+	section, ok := folders.(map[string]interface{})
+	if !ok {
+		return libraries, nil
+	}
+
+	// libraries are keyed by their index, alongside other (non numeric)
+	// values in the former format
+	var indexes []int
+	for key := range section {
+		if index, err := strconv.Atoi(key); err == nil {
+			indexes = append(indexes, index)
+		}
+	}
+
+	sort.Ints(indexes)
+
+	seen := map[string]bool{filepath.Clean(steamRoot): true}
+
+	for _, index := range indexes {
+		var library string
+
+		switch folder := section[strconv.Itoa(index)].(type) {
+		case string:
+			library = folder
+
+		case map[string]interface{}:
+			library, _ = lookupString(folder, "path")
+		}
+
+		if library == "" || seen[filepath.Clean(library)] {
+			continue
+		}
+
+		seen[filepath.Clean(library)] = true
+		libraries = append(libraries, library)
+	}
+
+	return libraries, nil
+}
+
+// newInstall returns the Install described by the manifest at manifestPath.
+func newInstall(manifestPath string) (*Install, error) {
+
+	data, err := parser.Parse(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	state, ok := parser.LookupFold(data, "AppState")
+	if !ok {
+		return nil, fmt.Errorf("unable to locate \"AppState\" in %s", manifestPath)
+	}
+
+	installDir, _ := lookupString(state, "installdir")
+	if installDir == "" {
+		return nil, fmt.Errorf("unable to locate \"AppState/installdir\" in %s", manifestPath)
+	}
+
+	buildID, _ := lookupString(state, "buildid")
+
+	install := &Install{
+		Path:    filepath.Join(filepath.Dir(manifestPath), "common", installDir),
+		BuildID: buildID,
+	}
+
+	if _, err := os.Stat(install.Path); err != nil {
+		return nil, fmt.Errorf("%w: install directory %s of app %s", ErrNotFound, install.Path, AppID)
+	}
+
+	game := filepath.Join(install.Path, filepath.FromSlash(gameDir))
+
+	install.ItemsGame, err = findItemsGame(game)
+	if err != nil {
+		return nil, err
+	}
+
+	install.Languages, err = findLanguages(game)
+	if err != nil {
+		return nil, err
+	}
+
+	return install, nil
+}
+
+// findItemsGame returns the location of items_game.txt within the game
+// directory, preferring a loose file over the game's package.
+func findItemsGame(game string) (Location, error) {
+
+	loose := filepath.Join(game, filepath.FromSlash(itemsGamePath))
+	if _, err := os.Stat(loose); err == nil {
+		return Location{Path: loose}, nil
+	}
+
+	packageFile := filepath.Join(game, packagePath)
+
+	archive, err := vpk.Open(packageFile)
+	if err != nil {
+		return Location{}, fmt.Errorf("%w: items_game.txt isn't loose, and %v", ErrNotFound, err)
+	}
+
+	defer archive.Close()
+
+	if _, ok := archive.Entry(itemsGamePath); !ok {
+		return Location{}, fmt.Errorf("%w: items_game.txt isn't loose, nor within %s", ErrNotFound, packageFile)
+	}
+
+	return Location{VPK: packageFile, Path: itemsGamePath}, nil
+}
+
+// findLanguages returns the location of each csgo_<language>.txt file within
+// the game directory, keyed by lowercased language. Files matching the name
+// that aren't language files (see isLanguageFile) are ignored.
+func findLanguages(game string) (map[string]Location, error) {
+
+	matches, err := filepath.Glob(filepath.Join(game, languageDir, "csgo_*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(map[string]Location, len(matches))
+
+	for _, match := range matches {
+		language := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), "csgo_"), ".txt")
+
+		if !isLanguageFile(match, language) {
+			continue
+		}
+
+		response[strings.ToLower(language)] = Location{Path: match}
+	}
+
+	return response, nil
+}
+
+// isLanguageFile returns whether the file at the provided path is the
+// language file of the provided language, i.e. begins with a "lang" section
+// whose first entry is its "Language", matching the provided language
+// regardless of case. Only the beginning of the file is read.
+//
+// This excludes the other files of the game whose names also match
+// csgo_*.txt, such as those of other resources in each language (e.g.
+// csgo_rich_presence_english.txt).
+func isLanguageFile(path, language string) bool {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+
+	defer f.Close()
+
+	d := parser.NewDecoder(f)
+
+	t, err := d.Token()
+	if err != nil {
+		return false
+	}
+
+	if start, ok := t.(parser.StartSection); !ok || !strings.EqualFold(start.Key, "lang") {
+		return false
+	}
+
+	t, err = d.Token()
+	if err != nil {
+		return false
+	}
+
+	entry, ok := t.(parser.KeyValue)
+
+	return ok && strings.EqualFold(entry.Key, "Language") && strings.EqualFold(entry.Value, language)
+}
+
+// lookupString returns the string value of the provided key within value
+// (which must be a section), matched regardless of case.
+func lookupString(value interface{}, key string) (string, bool) {
+
+	section, ok := value.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+
+	found, ok := parser.LookupFold(section, key)
+	if !ok {
+		return "", false
+	}
+
+	s, ok := found.(string)
+
+	return s, ok
+}
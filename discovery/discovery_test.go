@@ -0,0 +1,208 @@
+package discovery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the provided files (keyed by slash separated path) to
+// dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+
+	t.Helper()
+
+	for name, data := range files {
+		location := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(location), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(location, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeInstall writes an install of the game to library, made up of a
+// manifest and the provided game files (relative to gameDir).
+func writeInstall(t *testing.T, library string, files map[string]string) {
+
+	t.Helper()
+
+	writeFiles(t, library, map[string]string{
+		"steamapps/appmanifest_730.acf": "\"AppState\"\n{\n\t\"appid\"\t\t\"730\"\n\t\"installdir\"\t\t\"Counter-Strike Global Offensive\"\n\t\"buildid\"\t\t\"12345\"\n}\n",
+	})
+
+	game := filepath.Join(library, "steamapps", "common", "Counter-Strike Global Offensive", filepath.FromSlash(gameDir))
+
+	writeFiles(t, game, files)
+}
+
+func TestLibraries(t *testing.T) {
+
+	for _, test := range []struct {
+		name     string
+		folders  string
+		expected []string
+	}{
+		{
+			name:     "no libraryfolders.vdf",
+			expected: []string{""},
+		},
+		{
+			name:     "current format",
+			folders:  "\"libraryfolders\"\n{\n\t\"0\"\n\t{\n\t\t\"path\"\t\t\"<root>\"\n\t}\n\t\"1\"\n\t{\n\t\t\"path\"\t\t\"/mnt/games\"\n\t\t\"apps\"\n\t\t{\n\t\t\t\"730\"\t\t\"1000\"\n\t\t}\n\t}\n}\n",
+			expected: []string{"", "/mnt/games"},
+		},
+		{
+			name:     "former format",
+			folders:  "\"LibraryFolders\"\n{\n\t\"TimeNextStatsReport\"\t\t\"1700000000\"\n\t\"ContentStatsID\"\t\t\"-1\"\n\t\"1\"\t\t\"/mnt/games\"\n}\n",
+			expected: []string{"", "/mnt/games"},
+		},
+		{
+			name:     "ordered by index",
+			folders:  "\"libraryfolders\"\n{\n\t\"10\"\t\t\"/mnt/c\"\n\t\"2\"\t\t\"/mnt/b\"\n\t\"1\"\t\t\"/mnt/a\"\n}\n",
+			expected: []string{"", "/mnt/a", "/mnt/b", "/mnt/c"},
+		},
+		{
+			name:     "duplicates",
+			folders:  "\"libraryfolders\"\n{\n\t\"1\"\t\t\"/mnt/a\"\n\t\"2\"\t\t\"/mnt/a/\"\n\t\"3\"\t\t\"<root>\"\n\t\"4\"\t\t\"\"\n}\n",
+			expected: []string{"", "/mnt/a"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			root := t.TempDir()
+
+			if err := os.MkdirAll(filepath.Join(root, "steamapps"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.folders != "" {
+				writeFiles(t, root, map[string]string{
+					"steamapps/libraryfolders.vdf": strings.ReplaceAll(test.folders, "<root>", filepath.ToSlash(root)),
+				})
+			}
+
+			libraries, err := Libraries(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the steam root is represented by an empty string
+			expected := make([]string, len(test.expected))
+			for i, library := range test.expected {
+				if library == "" {
+					library = root
+				}
+
+				expected[i] = library
+			}
+
+			if strings.Join(libraries, ",") != strings.Join(expected, ",") {
+				t.Errorf("Libraries = %v, expected %v", libraries, expected)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+
+	root := t.TempDir()
+	library := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"steamapps/libraryfolders.vdf": "\"libraryfolders\"\n{\n\t\"0\"\n\t{\n\t\t\"path\"\t\t\"" + filepath.ToSlash(root) + "\"\n\t}\n\t\"1\"\n\t{\n\t\t\"path\"\t\t\"" + filepath.ToSlash(library) + "\"\n\t}\n}\n",
+	})
+
+	writeInstall(t, library, map[string]string{
+		"scripts/items/items_game.txt":            "\"items_game\"\n{\n\t\"items\"\n\t{\n\t}\n}\n",
+		"resource/csgo_english.txt":               "\"lang\"\n{\n\t\"Language\"\t\t\"English\"\n\t\"Tokens\"\n\t{\n\t}\n}\n",
+		"resource/csgo_schinese.txt":              "// a comment preceding the file\n\"Lang\" { \"language\" \"schinese\" \"Tokens\" { } }",
+		"resource/csgo_rich_presence_english.txt": "\"lang\"\n{\n\t\"Language\"\t\t\"English\"\n\t\"Tokens\"\n\t{\n\t}\n}\n",
+		"resource/csgo_tokens.txt":                "\"lang\"\n{\n\t\"Tokens\"\n\t{\n\t}\n}\n",
+		"resource/csgo_notes.txt":                 "not a language file",
+		"resource/csgo_empty.txt":                 "",
+	})
+
+	install, err := Find(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if install.SteamRoot != root || install.Library != library {
+		t.Errorf("SteamRoot, Library = %s, %s, expected %s, %s", install.SteamRoot, install.Library, root, library)
+	}
+
+	if expected := filepath.Join(library, "steamapps", "common", "Counter-Strike Global Offensive"); install.Path != expected {
+		t.Errorf("Path = %s, expected %s", install.Path, expected)
+	}
+
+	if install.BuildID != "12345" {
+		t.Errorf("BuildID = %s, expected 12345", install.BuildID)
+	}
+
+	if install.ItemsGame.VPK != "" || filepath.Base(install.ItemsGame.Path) != "items_game.txt" {
+		t.Errorf("ItemsGame = %s, expected loose items_game.txt", install.ItemsGame)
+	}
+
+	if _, err := install.ItemsGame.Parse(); err != nil {
+		t.Errorf("ItemsGame.Parse: %v", err)
+	}
+
+	var languages []string
+	for language := range install.Languages {
+		languages = append(languages, language)
+	}
+
+	sort.Strings(languages)
+
+	if strings.Join(languages, ",") != "english,schinese" {
+		t.Errorf("Languages = %v, expected [english schinese]", languages)
+	}
+
+	if location, ok := install.Language("English"); !ok || filepath.Base(location.Path) != "csgo_english.txt" {
+		t.Errorf("Language(English) = %s, %v, expected csgo_english.txt", location, ok)
+	}
+}
+
+func TestFindNotFound(t *testing.T) {
+
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+		game  map[string]string
+	}{
+		{
+			name: "no Steam installation",
+		},
+		{
+			name:  "not installed",
+			files: map[string]string{"steamapps/libraryfolders.vdf": "\"libraryfolders\" { }"},
+		},
+		{
+			name: "no items_game.txt",
+			game: map[string]string{"resource/csgo_english.txt": "\"lang\" { \"Language\" \"English\" }"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			root := t.TempDir()
+
+			writeFiles(t, root, test.files)
+
+			if test.game != nil {
+				writeInstall(t, root, test.game)
+			}
+
+			if _, err := Find(root); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Find error = %v, expected ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -2,10 +2,10 @@ module github.com/rustedturnip/go-csgo-item-parser
 
 go 1.19
 
-require github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3
-
 require (
-	github.com/pkg/errors v0.9.1 // indirect
-	github.com/shopspring/decimal v1.3.1 // indirect
-	golang.org/x/exp v0.0.0-20230210204819-062eb4c674ab // indirect
+	github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3
+	github.com/pkg/errors v0.9.1
+	github.com/shopspring/decimal v1.3.1
 )
+
+require golang.org/x/exp v0.0.0-20230210204819-062eb4c674ab // indirect
@@ -3,35 +3,101 @@ package main
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"github.com/rustedturnip/go-csgo-item-parser/csgo"
 	"os"
+	"strings"
 
+	"github.com/rustedturnip/go-csgo-item-parser/discovery"
 	"github.com/rustedturnip/go-csgo-item-parser/parser"
+	"github.com/rustedturnip/go-csgo-item-parser/vpk"
+)
+
+const (
+	// vpkItemsLocation is the path of the items_game.txt file within the
+	// game's pak01_dir.vpk package
+	vpkItemsLocation = "scripts/items/items_game.txt"
 )
 
 var (
 	csgoItemsLocation    string
 	csgoLanguageLocation string
 	outputLocation       string
+	vpkLocation          string
+
+	discover     bool
+	steamRoot    string
+	languageName string
+
+	csgoItemsOverlays    fileList
+	csgoLanguageOverlays fileList
 )
 
+// fileList is a flag.Value holding the file locations of a flag that can be
+// provided multiple times.
+type fileList []string
+
+// String implements flag.Value.
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set implements flag.Value.
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 func init() {
 	flag.StringVar(&csgoItemsLocation, "csgo-items", "/items_game.txt", "the path to the csgo_items.txt file")
 	flag.StringVar(&csgoLanguageLocation, "csgo-language", "/csgo_english.txt", "the path to the csgo_english.txt file")
+	flag.StringVar(&vpkLocation, "vpk", "", "the path to the game's pak01_dir.vpk file, from which the items_game.txt file is read (in place of --csgo-items)")
 	flag.StringVar(&outputLocation, "output", "/result.json", "the path to resulting json output file")
+	flag.BoolVar(&discover, "discover", false, "locate the items_game.txt and csgo_<language>.txt files from the Steam install of the game (in place of --csgo-items, --csgo-language and --vpk where not provided)")
+	flag.StringVar(&steamRoot, "steam-root", "", "the path to the Steam installation used by --discover (defaults to the standard locations)")
+	flag.StringVar(&languageName, "language", "english", "the language of the csgo_<language>.txt file used by --discover")
+	flag.Var(&csgoItemsOverlays, "csgo-items-overlay", "the path to a file to merge on top of the csgo_items.txt file (can be repeated)")
+	flag.Var(&csgoLanguageOverlays, "csgo-language-overlay", "the path to a file to merge on top of the csgo_english.txt file (can be repeated)")
 }
 
 func main() {
 
+	// subcommands
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		if err := runQuery(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	flag.Parse()
 
+	if discover {
+		if err := discoverLocations(); err != nil {
+			panic(err)
+		}
+	}
+
 	// read data
 	languageData, err := parser.Parse(csgoLanguageLocation)
 	if err != nil {
 		panic(err)
 	}
 
-	itemData, err := parser.Parse(csgoItemsLocation)
+	itemData, err := parseItems()
+	if err != nil {
+		panic(err)
+	}
+
+	// apply overlays
+	languageData, err = applyOverlays(languageData, csgoLanguageOverlays)
+	if err != nil {
+		panic(err)
+	}
+
+	itemData, err = applyOverlays(itemData, csgoItemsOverlays)
 	if err != nil {
 		panic(err)
 	}
@@ -60,3 +126,85 @@ func main() {
 		panic(err)
 	}
 }
+
+// discoverLocations sets the locations of the items_game.txt and
+// csgo_<language>.txt files from the Steam install of the game, leaving any
+// provided explicitly as they are.
+func discoverLocations() error {
+
+	install, err := discovery.Find(steamRoot)
+	if err != nil {
+		return err
+	}
+
+	provided := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		provided[f.Name] = true
+	})
+
+	if !provided["csgo-items"] && !provided["vpk"] {
+		csgoItemsLocation = install.ItemsGame.Path
+		vpkLocation = install.ItemsGame.VPK
+	}
+
+	if !provided["csgo-language"] {
+		language, ok := install.Language(languageName)
+		if !ok {
+			return fmt.Errorf("no csgo_%s.txt file found within %s", languageName, install.Path)
+		}
+
+		csgoLanguageLocation = language.Path
+	}
+
+	fmt.Fprintf(os.Stderr, "discovered build %s at %s\n", install.BuildID, install.Path)
+
+	return nil
+}
+
+// parseItems parses the items_game.txt file, reading it from the VPK package
+// where one has been provided.
+func parseItems() (map[string]interface{}, error) {
+
+	if vpkLocation == "" {
+		return parser.Parse(csgoItemsLocation)
+	}
+
+	archive, err := vpk.Open(vpkLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	defer archive.Close()
+
+	return parser.ParseFS(archive, vpkItemsLocation)
+}
+
+// applyOverlays parses each of the overlay files and merges them, in order,
+// on top of data, logging the keys each changed to stderr.
+func applyOverlays(data map[string]interface{}, overlayLocations []string) (map[string]interface{}, error) {
+
+	if len(overlayLocations) == 0 {
+		return data, nil
+	}
+
+	overlays := make([]map[string]interface{}, len(overlayLocations))
+
+	for i, location := range overlayLocations {
+		overlay, err := parser.Parse(location)
+		if err != nil {
+			return nil, err
+		}
+
+		overlays[i] = overlay
+	}
+
+	merged, report := parser.Merge(data, overlays...)
+
+	for i, changes := range report {
+		for _, change := range changes {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", overlayLocations[i], change)
+		}
+	}
+
+	return merged, nil
+}
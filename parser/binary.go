@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf16"
+)
+
+// binary KeyValues type tags, each of which precedes the key of an entry
+const (
+	binaryTypeNone    byte = 0x00
+	binaryTypeString  byte = 0x01
+	binaryTypeInt32   byte = 0x02
+	binaryTypeFloat32 byte = 0x03
+	binaryTypePointer byte = 0x04
+	binaryTypeWString byte = 0x05
+	binaryTypeColor   byte = 0x06
+	binaryTypeUint64  byte = 0x07
+	binaryTypeEnd     byte = 0x08
+	binaryTypeInt64   byte = 0x0A
+
+	// binaryTypeAltEnd is an alternate end tag used by some files
+	binaryTypeAltEnd byte = 0x0B
+)
+
+// ParseBinary parses binary KeyValues (as used by appinfo.vdf and
+// packageinfo.vdf) read from r into a tree of Nodes, returning the root
+// section of the document in the same way as ParseTree.
+//
+// Each entry is a type tag, a null terminated key and a value, where the
+// value of a section is its entries followed by an end tag. Typed values are
+// retained through the NodeType of each Node (e.g. Int32Node), with the value
+// held in its text form so that the tree can be used in the same way as one
+// produced from text. Parsing ends at an end tag outside of any section, or
+// at the end of the data.
+func ParseBinary(r io.Reader) (*Node, error) {
+
+	br := &binaryReader{
+		r: bufio.NewReader(r),
+	}
+
+	root := NewSection("")
+
+	if err := br.readChildren(root, true); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// binaryReader reads binary KeyValues, tracking the offset for use within
+// errors.
+type binaryReader struct {
+	r      *bufio.Reader
+	offset int64
+}
+
+// readChildren reads entries into parent until an end tag is reached. Where
+// root is true, reaching the end of the data also ends the section.
+func (b *binaryReader) readChildren(parent *Node, root bool) error {
+
+	for {
+		offset := b.offset
+
+		t, err := b.readByte()
+		if err == io.EOF && root {
+			return nil
+		}
+
+		if err != nil {
+			return b.wrap(err, "type tag")
+		}
+
+		if t == binaryTypeEnd || t == binaryTypeAltEnd {
+			return nil
+		}
+
+		key, err := b.readString()
+		if err != nil {
+			return b.wrap(err, "key")
+		}
+
+		node := &Node{
+			Key: key,
+		}
+
+		switch t {
+		case binaryTypeNone:
+			node.Type = SectionNode
+
+			if err := b.readChildren(node, false); err != nil {
+				return err
+			}
+
+		case binaryTypeString:
+			node.Type = ValueNode
+			node.Value, err = b.readString()
+
+		case binaryTypeInt32:
+			node.Type = Int32Node
+
+			var v int32
+			v, err = readFixed[int32](b)
+			node.Value = strconv.FormatInt(int64(v), 10)
+
+		case binaryTypeFloat32:
+			node.Type = Float32Node
+
+			var v uint32
+			v, err = readFixed[uint32](b)
+			node.Value = strconv.FormatFloat(float64(math.Float32frombits(v)), 'f', -1, 32)
+
+		case binaryTypePointer:
+			node.Type = PointerNode
+
+			var v uint32
+			v, err = readFixed[uint32](b)
+			node.Value = strconv.FormatUint(uint64(v), 10)
+
+		case binaryTypeWString:
+			node.Type = WStringNode
+			node.Value, err = b.readWString()
+
+		case binaryTypeColor:
+			node.Type = ColorNode
+
+			var v [4]byte
+			v, err = readFixed[[4]byte](b)
+			node.Value = fmt.Sprintf("%d %d %d %d", v[0], v[1], v[2], v[3])
+
+		case binaryTypeUint64:
+			node.Type = Uint64Node
+
+			var v uint64
+			v, err = readFixed[uint64](b)
+			node.Value = strconv.FormatUint(v, 10)
+
+		case binaryTypeInt64:
+			node.Type = Int64Node
+
+			var v int64
+			v, err = readFixed[int64](b)
+			node.Value = strconv.FormatInt(v, 10)
+
+		default:
+			return fmt.Errorf("unexpected binary KeyValues type tag 0x%02x at offset %d", t, offset)
+		}
+
+		if err != nil {
+			return b.wrap(err, fmt.Sprintf("value of key \"%s\"", key))
+		}
+
+		parent.Children = append(parent.Children, node)
+	}
+}
+
+// readByte reads a single byte.
+func (b *binaryReader) readByte() (byte, error) {
+
+	c, err := b.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	b.offset++
+
+	return c, nil
+}
+
+// readString reads a null terminated (UTF-8) string.
+func (b *binaryReader) readString() (string, error) {
+
+	s, err := b.r.ReadString(0)
+	b.offset += int64(len(s))
+
+	if err != nil {
+		return "", err
+	}
+
+	return s[:len(s)-1], nil
+}
+
+// readWString reads a length prefixed UTF-16 (little endian) string.
+func (b *binaryReader) readWString() (string, error) {
+
+	length, err := readFixed[uint16](b)
+	if err != nil {
+		return "", err
+	}
+
+	units := make([]uint16, length)
+	if err := binary.Read(b.r, binary.LittleEndian, units); err != nil {
+		return "", err
+	}
+
+	b.offset += int64(length) * 2
+
+	// drop null terminator where included in length
+	if length > 0 && units[length-1] == 0 {
+		units = units[:length-1]
+	}
+
+	return string(utf16.Decode(units)), nil
+}
+
+// wrap returns an error describing the failure to read the named part of an
+// entry at the current offset.
+func (b *binaryReader) wrap(err error, part string) error {
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return fmt.Errorf("unable to read binary KeyValues %s at offset %d: %w", part, b.offset, err)
+}
+
+// readFixed reads a fixed size little endian value of type T.
+func readFixed[T any](b *binaryReader) (T, error) {
+
+	var v T
+
+	if err := binary.Read(b.r, binary.LittleEndian, &v); err != nil {
+		return v, err
+	}
+
+	b.offset += int64(binary.Size(v))
+
+	return v, nil
+}
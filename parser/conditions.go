@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conditions is the set of symbols (e.g. "$WIN32") that are defined when
+// evaluating the conditionals that can follow a key or value, such as
+// "key" "value" [$WIN32]. Symbols are matched case-insensitively.
+type Conditions map[string]bool
+
+// NewConditions returns Conditions with each of the provided symbols defined.
+func NewConditions(symbols ...string) Conditions {
+
+	response := make(Conditions)
+
+	for _, symbol := range symbols {
+		response[strings.ToUpper(symbol)] = true
+	}
+
+	return response
+}
+
+// Evaluate returns whether the provided conditional expression (without its
+// surrounding brackets) holds for the defined symbols. Expressions consist of
+// symbols, optionally negated with "!", combined with "&&" and "||" (where
+// "&&" takes precedence), e.g. "$WIN32||$OSX" or "!$X360".
+func (c Conditions) Evaluate(expr string) (bool, error) {
+
+	for _, or := range strings.Split(expr, "||") {
+
+		result := true
+
+		for _, and := range strings.Split(or, "&&") {
+
+			symbol := strings.TrimSpace(and)
+
+			negate := strings.HasPrefix(symbol, "!")
+			symbol = strings.TrimSpace(strings.TrimPrefix(symbol, "!"))
+
+			if !strings.HasPrefix(symbol, "$") || len(symbol) == 1 {
+				return false, fmt.Errorf("invalid conditional [%s]", expr)
+			}
+
+			if c.defined(symbol) == negate {
+				result = false
+			}
+		}
+
+		if result {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// defined returns whether the provided symbol is defined, matching it
+// against the symbols regardless of case (as Conditions can be built
+// directly, as well as through NewConditions).
+func (c Conditions) defined(symbol string) bool {
+
+	if defined, ok := c[strings.ToUpper(symbol)]; ok {
+		return defined
+	}
+
+	for key, defined := range c {
+		if strings.EqualFold(key, symbol) && defined {
+			return true
+		}
+	}
+
+	return false
+}
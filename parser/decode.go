@@ -0,0 +1,351 @@
+package parser
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	nodeType            = reflect.TypeOf(Node{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// UnmarshalError describes a failure to store a VDF entry in a Go value,
+// along with the path of keys to the offending entry.
+type UnmarshalError struct {
+	// Path is the "/" separated path of keys to the entry
+	Path string
+
+	// Line is the line of the entry within the source (0 where the entry is
+	// missing or wasn't parsed from a source)
+	Line int
+
+	Err error
+}
+
+func (e *UnmarshalError) Error() string {
+
+	if e.Line > 0 {
+		return fmt.Sprintf("unable to unmarshal %s (line %d): %s", e.Path, e.Line, e.Err.Error())
+	}
+
+	return fmt.Sprintf("unable to unmarshal %s: %s", e.Path, e.Err.Error())
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// Unmarshal parses the VDF data and stores the result in the value pointed
+// to by v. See UnmarshalNode for how entries are stored.
+func Unmarshal(data []byte, v interface{}) error {
+
+	tree, err := ParseTree(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	return UnmarshalNode(tree, v)
+}
+
+// UnmarshalNode stores the children of the provided Node in the value
+// pointed to by v.
+//
+// Sections can be stored in structs, where each field is populated from the
+// entry with the key given in the field's `vdf:"key"` tag (or otherwise the
+// field's name), or in maps with string or integer keys (e.g. index keyed
+// sections such as paint_kits). Key matching is exact where possible, falling
+// back to a case-insensitive match.
+//
+// Values can be stored in strings, integers, floats, bools, any type
+// implementing encoding.TextUnmarshaler (e.g. decimal.Decimal), or
+// interface{} values (as either a string or map[string]interface{}).
+//
+// A struct field holding a slice is populated from every entry with the
+// field's key, allowing duplicate keys to be retained. A field of type *Node
+// is populated with the entry itself.
+//
+// Fields are optional unless tagged as required (e.g. `vdf:"name,required"`),
+// in which case an error is returned when the entry is missing.
+func UnmarshalNode(n *Node, v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("unable to unmarshal into non-pointer or nil value of type %T", v)
+	}
+
+	return decodeNode(n, rv.Elem(), "")
+}
+
+// tagOptions holds the options that can follow the key of a `vdf` tag.
+type tagOptions struct {
+	omitEmpty bool
+	required  bool
+}
+
+// parseTag returns the key and options of the provided struct field. If the
+// field is to be ignored, skip is returned as true.
+func parseTag(field reflect.StructField) (key string, opts tagOptions, skip bool) {
+
+	// ignore unexported fields
+	if !field.IsExported() {
+		return "", opts, true
+	}
+
+	tag := field.Tag.Get("vdf")
+	if tag == "-" {
+		return "", opts, true
+	}
+
+	parts := strings.Split(tag, ",")
+
+	key = parts[0]
+	if key == "" {
+		key = field.Name
+	}
+
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "omitempty":
+			opts.omitEmpty = true
+
+		case "required":
+			opts.required = true
+		}
+	}
+
+	return key, opts, false
+}
+
+// joinPath appends key to the provided "/" separated path.
+func joinPath(path, key string) string {
+
+	if path == "" {
+		return key
+	}
+
+	return path + "/" + key
+}
+
+// decodeNode stores the provided node in rv.
+func decodeNode(n *Node, rv reflect.Value, path string) error {
+
+	// allocate pointers as required
+	for rv.Kind() == reflect.Pointer {
+
+		if rv.Type().Elem() == nodeType {
+			rv.Set(reflect.ValueOf(n))
+			return nil
+		}
+
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	if !n.IsSection() {
+		return decodeValue(n, rv, path)
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return decodeStruct(n, rv, path)
+
+	case reflect.Map:
+		return decodeMap(n, rv, path)
+
+	case reflect.Interface:
+		if rv.NumMethod() == 0 {
+			rv.Set(reflect.ValueOf(n.Map()))
+			return nil
+		}
+	}
+
+	return &UnmarshalError{
+		Path: path,
+		Line: n.Line,
+		Err:  fmt.Errorf("cannot store section in value of type %s", rv.Type()),
+	}
+}
+
+// decodeStruct stores the children of the provided node in the fields of the
+// struct rv.
+func decodeStruct(n *Node, rv reflect.Value, path string) error {
+
+	t := rv.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+
+		key, opts, skip := parseTag(field)
+		if skip {
+			continue
+		}
+
+		fieldPath := joinPath(path, key)
+		matches := findFold(n, key)
+
+		if len(matches) == 0 {
+			if opts.required {
+				return &UnmarshalError{
+					Path: fieldPath,
+					Err:  fmt.Errorf("required key is missing"),
+				}
+			}
+
+			continue
+		}
+
+		fv := rv.Field(i)
+
+		// slices (other than []byte) are populated from every matching entry
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+
+			slice := reflect.MakeSlice(fv.Type(), len(matches), len(matches))
+
+			for j, match := range matches {
+				if err := decodeNode(match, slice.Index(j), fmt.Sprintf("%s[%d]", fieldPath, j)); err != nil {
+					return err
+				}
+			}
+
+			fv.Set(slice)
+			continue
+		}
+
+		// otherwise the last entry takes precedence (as with Parse)
+		if err := decodeNode(matches[len(matches)-1], fv, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findFold returns the children of n with the provided key, falling back to
+// a case-insensitive match where no exact matches exist.
+func findFold(n *Node, key string) []*Node {
+
+	matches := n.FindAll(key)
+	if len(matches) > 0 {
+		return matches
+	}
+
+	return n.FindAllFold(key)
+}
+
+// decodeMap stores the children of the provided node in the map rv, with the
+// key of each child converted to the key type of the map.
+func decodeMap(n *Node, rv reflect.Value, path string) error {
+
+	t := rv.Type()
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(t))
+	}
+
+	for _, child := range n.Children {
+
+		childPath := joinPath(path, child.Key)
+
+		key := reflect.New(t.Key()).Elem()
+		if err := setScalar(key, child.Key); err != nil {
+			return &UnmarshalError{
+				Path: childPath,
+				Line: child.Line,
+				Err:  fmt.Errorf("invalid map key: %s", err.Error()),
+			}
+		}
+
+		// merge into existing entries (as with Parse) where present
+		elem := reflect.New(t.Elem()).Elem()
+		if existing := rv.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+
+		if err := decodeNode(child, elem, childPath); err != nil {
+			return err
+		}
+
+		rv.SetMapIndex(key, elem)
+	}
+
+	return nil
+}
+
+// decodeValue stores the value of the provided node in rv.
+func decodeValue(n *Node, rv reflect.Value, path string) error {
+
+	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+		rv.Set(reflect.ValueOf(n.Value))
+		return nil
+	}
+
+	if err := setScalar(rv, n.Value); err != nil {
+		return &UnmarshalError{
+			Path: path,
+			Line: n.Line,
+			Err:  err,
+		}
+	}
+
+	return nil
+}
+
+// setScalar converts the provided string s to the type of rv, and stores it
+// in rv.
+func setScalar(rv reflect.Value, s string) error {
+
+	if rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, rv.Type())
+		}
+
+		rv.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(s), 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, rv.Type())
+		}
+
+		rv.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, rv.Type())
+		}
+
+		rv.SetFloat(f)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, rv.Type())
+		}
+
+		rv.SetBool(b)
+
+	default:
+		return fmt.Errorf("cannot store value in value of type %s", rv.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,469 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+)
+
+// Token holds a single event read by a Decoder, which is one of
+// StartSection, KeyValue, EndSection or Directive.
+type Token interface{}
+
+// StartSection marks the start of a section with the provided key. The
+// entries of the section follow, up until the matching EndSection.
+type StartSection struct {
+	Key       string
+	Condition string
+
+	Line   int
+	Column int
+}
+
+// KeyValue is a single key value pair.
+type KeyValue struct {
+	Key       string
+	Value     string
+	Condition string
+
+	Line   int
+	Column int
+}
+
+// EndSection marks the end of the most recently started section, at the
+// position of its closing brace.
+type EndSection struct {
+	Line   int
+	Column int
+}
+
+// Directive is a #base or #include directive (identified by Kind), which
+// can only appear outside of any section. Directives aren't resolved by the
+// Decoder itself.
+type Directive struct {
+	Kind      string
+	Name      string
+	Condition string
+
+	Line   int
+	Column int
+}
+
+// Decoder reads VDF data from an input stream as a sequence of Tokens,
+// without building the document in memory.
+//
+// The data is made up of entries, each of which is a key followed by either
+// a value or a section of further entries enclosed in braces. Keys and values
+// can be quoted or unquoted, and keys, values and directives can each be
+// followed by a conditional, e.g.
+//
+//	"key" "value" [$WIN32]
+//	key [!$X360] { "a" "b" }
+type Decoder struct {
+	r    io.Reader
+	lex  *lexer
+	opts ParseOptions
+
+	// open holds the sections currently open, excluding the root of the
+	// document
+	open []openSection
+
+	// errs holds the errors encountered in lenient mode
+	errs ErrorList
+
+	// err holds the error (including io.EOF) that ended decoding, which is
+	// returned from every subsequent call to Token
+	err error
+}
+
+// openSection is a section opened by a Decoder.
+type openSection struct {
+	key  string
+	line int
+
+	// emit is whether the section's tokens are returned, which they aren't
+	// for sections excluded by a conditional (or within an excluded section)
+	emit bool
+}
+
+// eventType identifies the type of an event.
+type eventType int
+
+const (
+	eventNone eventType = iota
+	eventStartSection
+	eventKeyValue
+	eventEndSection
+	eventDirective
+)
+
+// event is the internal representation of a Token, which avoids allocating
+// a Token for each event where a Decoder is used within this package. For
+// an eventDirective, key holds the directive's kind and value its file name.
+type event struct {
+	typ       eventType
+	key       string
+	value     string
+	condition string
+	line      int
+	column    int
+}
+
+// token returns the Token for the event.
+func (e event) token() Token {
+
+	switch e.typ {
+	case eventStartSection:
+		return StartSection{Key: e.key, Condition: e.condition, Line: e.line, Column: e.column}
+
+	case eventKeyValue:
+		return KeyValue{Key: e.key, Value: e.value, Condition: e.condition, Line: e.line, Column: e.column}
+
+	case eventEndSection:
+		return EndSection{Line: e.line, Column: e.column}
+
+	case eventDirective:
+		return Directive{Kind: e.key, Name: e.value, Condition: e.condition, Line: e.line, Column: e.column}
+	}
+
+	return nil
+}
+
+// NewDecoder returns a new Decoder that reads from r. It is the
+// responsibility of the caller to close r where required.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, ParseOptions{})
+}
+
+// NewDecoderWithOptions returns a new Decoder that reads from r, applying
+// the provided options. As the Decoder doesn't resolve directives, the
+// Resolver of the options is unused.
+func NewDecoderWithOptions(r io.Reader, opts ParseOptions) *Decoder {
+	return &Decoder{
+		r:    r,
+		opts: opts,
+	}
+}
+
+// Token returns the next Token of the data, or io.EOF once the end of the
+// data is reached (with every section having been closed).
+//
+// Entries (and the contents of sections) whose conditional doesn't hold for
+// the Conditions of the Decoder's options are skipped. Syntax errors are
+// returned as a *ParseError, or in lenient mode, are recorded (see Errors)
+// with decoding continuing from the next token.
+func (d *Decoder) Token() (Token, error) {
+
+	e, err := d.event()
+	if err != nil {
+		return nil, err
+	}
+
+	return e.token(), nil
+}
+
+// event returns the next event of the data, as Token does.
+func (d *Decoder) event() (event, error) {
+
+	if d.err != nil {
+		return event{}, d.err
+	}
+
+	if d.lex == nil {
+		r, err := newDecodingReader(d.r, d.opts.Encoding)
+		if err != nil {
+			d.err = err
+			return event{}, err
+		}
+
+		d.lex = newLexer(r, !d.opts.DisableEscapes)
+	}
+
+	for {
+		e, err := d.next()
+		if err != nil {
+			d.err = err
+			return event{}, err
+		}
+
+		if e.typ != eventNone {
+			return e, nil
+		}
+	}
+}
+
+// Skip skips the remaining Tokens of the most recently started section, up
+// to and including its EndSection.
+func (d *Decoder) Skip() error {
+
+	depth := 1
+
+	for depth > 0 {
+		e, err := d.event()
+		if err != nil {
+			return err
+		}
+
+		switch e.typ {
+		case eventStartSection:
+			depth++
+
+		case eventEndSection:
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// Errors returns the errors recorded so far in lenient mode.
+func (d *Decoder) Errors() ErrorList {
+	return d.errs
+}
+
+// fail handles the provided ParseError. In lenient mode, the error is
+// recorded and nil is returned so that decoding can continue, otherwise the
+// error is returned.
+func (d *Decoder) fail(err error) error {
+
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		return err
+	}
+
+	parseErr.Name = d.opts.Name
+	parseErr.Snippet = d.lex.snippet(parseErr.lineOffset)
+
+	if !d.opts.Lenient {
+		return err
+	}
+
+	d.errs = append(d.errs, parseErr)
+
+	return nil
+}
+
+// emitting returns whether the tokens of the current section are returned.
+func (d *Decoder) emitting() bool {
+
+	if len(d.open) == 0 {
+		return true
+	}
+
+	return d.open[len(d.open)-1].emit
+}
+
+// pop removes and returns the most recently opened section.
+func (d *Decoder) pop() openSection {
+
+	open := d.open[len(d.open)-1]
+	d.open = d.open[:len(d.open)-1]
+
+	return open
+}
+
+// next reads the next token of the lexer, returning the event it results in
+// (which is of type eventNone where there isn't one).
+func (d *Decoder) next() (event, error) {
+
+	t, err := d.lex.next()
+	if err != nil {
+		return event{}, d.fail(err)
+	}
+
+	switch t.typ {
+
+	// end of data: all sections must have been closed
+	case tokenEOF:
+		for len(d.open) > 0 {
+			open := d.pop()
+
+			err := newParseError(t, fmt.Sprintf("unexpected end of data, section \"%s\" opened on line %d is not closed", open.key, open.line), "'}'")
+			if err := d.fail(err); err != nil {
+				return event{}, err
+			}
+		}
+
+		return event{}, io.EOF
+
+	// closer: close currently open section
+	case tokenClose:
+		// the root section can't be closed
+		if len(d.open) == 0 {
+			return event{}, d.fail(newUnexpectedError(t, "key"))
+		}
+
+		if open := d.pop(); !open.emit {
+			return event{}, nil
+		}
+
+		return event{typ: eventEndSection, line: t.line, column: t.column}, nil
+
+	case tokenOpen, tokenConditional:
+		if err := d.fail(newUnexpectedError(t, "key", "'}'")); err != nil {
+			return event{}, err
+		}
+
+		// an unnamed section is still parsed (but not emitted) so that its
+		// closing brace doesn't close the current section
+		if t.typ == tokenOpen {
+			d.open = append(d.open, openSection{})
+		}
+
+		return event{}, nil
+	}
+
+	// directives can only appear outside of any section
+	if kind := directiveKind(t.value); kind != "" && len(d.open) == 0 {
+		return d.parseDirective(kind, t)
+	}
+
+	return d.parseEntry(t)
+}
+
+// parseEntry parses the remainder of an entry following its key, returning
+// it as either an eventKeyValue or eventStartSection (with the section's
+// children returned as subsequent events). An eventNone is returned where the
+// entry is excluded, or in lenient mode, where the entry can't be parsed.
+func (d *Decoder) parseEntry(key token) (event, error) {
+
+	condition, err := d.conditional()
+	if err != nil {
+		return event{}, err
+	}
+
+	// the token following the key is only consumed where expected, so that
+	// decoding can continue from it in lenient mode
+	t, err := d.lex.peek()
+	if err != nil {
+		d.lex.next()
+		return event{}, d.fail(err)
+	}
+
+	switch t.typ {
+	case tokenOpen, tokenString:
+
+	default:
+		err := newUnexpectedError(t, "value", "'{'")
+		err.Msg += fmt.Sprintf(" following key \"%s\"", key.value)
+
+		return event{}, d.fail(err)
+	}
+
+	d.lex.next()
+
+	// data: value may be followed by a conditional
+	if t.typ == tokenString && condition == "" {
+		condition, err = d.conditional()
+		if err != nil {
+			return event{}, err
+		}
+	}
+
+	include, err := d.evaluateCondition(condition, key)
+	if err != nil {
+		return event{}, err
+	}
+
+	emit := include && d.emitting()
+
+	// section: children follow
+	if t.typ == tokenOpen {
+		d.open = append(d.open, openSection{key: key.value, line: key.line, emit: emit})
+
+		if !emit {
+			return event{}, nil
+		}
+
+		return event{
+			typ:       eventStartSection,
+			key:       key.value,
+			condition: condition,
+			line:      key.line,
+			column:    key.column,
+		}, nil
+	}
+
+	if !emit {
+		return event{}, nil
+	}
+
+	return event{
+		typ:       eventKeyValue,
+		key:       key.value,
+		value:     t.value,
+		condition: condition,
+		line:      key.line,
+		column:    key.column,
+	}, nil
+}
+
+// parseDirective parses the remainder of a #base or #include directive
+// following its kind, returning an eventNone where the directive is excluded
+// (or in lenient mode, can't be parsed).
+func (d *Decoder) parseDirective(kind string, t token) (event, error) {
+
+	name, err := d.lex.peek()
+	if err != nil {
+		d.lex.next()
+		return event{}, d.fail(err)
+	}
+
+	if name.typ != tokenString {
+		err := newUnexpectedError(name, "file name")
+		err.Msg += fmt.Sprintf(" following %s", kind)
+
+		return event{}, d.fail(err)
+	}
+
+	d.lex.next()
+
+	condition, err := d.conditional()
+	if err != nil {
+		return event{}, err
+	}
+
+	include, err := d.evaluateCondition(condition, t)
+	if err != nil || !include {
+		return event{}, err
+	}
+
+	return event{
+		typ:       eventDirective,
+		key:       kind,
+		value:     name.value,
+		condition: condition,
+		line:      t.line,
+		column:    t.column,
+	}, nil
+}
+
+// conditional consumes and returns the next token's expression if it is a
+// conditional, otherwise an empty string is returned.
+func (d *Decoder) conditional() (string, error) {
+
+	condition, err := d.lex.conditional()
+	if err != nil {
+		return "", d.fail(err)
+	}
+
+	return condition, nil
+}
+
+// evaluateCondition returns whether the provided conditional (following the
+// key t) holds for the Conditions of the Decoder's options. Where
+// conditionals aren't being evaluated, or the condition is empty, it is
+// always true.
+func (d *Decoder) evaluateCondition(condition string, t token) (bool, error) {
+
+	if condition == "" || d.opts.Conditions == nil {
+		return true, nil
+	}
+
+	include, err := d.opts.Conditions.Evaluate(condition)
+	if err != nil {
+		// invalid conditionals are treated as holding in lenient mode
+		return true, d.fail(newParseError(t, err.Error()))
+	}
+
+	return include, nil
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	directiveBase    = "#base"
+	directiveInclude = "#include"
+)
+
+var (
+	// ErrDirectiveCycle is returned (wrapped) when a #base or #include
+	// directive references a file that is already being parsed.
+	ErrDirectiveCycle = errors.New("cyclic #base/#include directive")
+)
+
+// Resolver opens the files referenced by #base and #include directives.
+type Resolver interface {
+
+	// Resolve opens the file referenced by a directive within the file named
+	// from (which is empty where the directive is within a document provided
+	// without a name). It returns the opened file along with its resolved
+	// name, which is used as from when resolving any directives the file
+	// itself contains, and to detect cycles.
+	Resolve(from, name string) (io.ReadCloser, string, error)
+}
+
+// DirResolver resolves directives against the operating system's file
+// system, relative to the directory of the including file. Directives within
+// a document provided without a name are resolved relative to Dir (or the
+// current working directory where Dir is empty).
+type DirResolver struct {
+	Dir string
+}
+
+// Resolve implements Resolver.
+func (d DirResolver) Resolve(from, name string) (io.ReadCloser, string, error) {
+
+	dir := d.Dir
+	if from != "" {
+		dir = filepath.Dir(from)
+	}
+
+	resolved := filepath.Join(dir, filepath.FromSlash(name))
+
+	fi, err := os.Open(resolved)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fi, resolved, nil
+}
+
+// FSResolver resolves directives against the provided fs.FS, relative to the
+// directory of the including file. Directives within a document provided
+// without a name are resolved relative to the root of the fs.FS.
+type FSResolver struct {
+	FS fs.FS
+}
+
+// Resolve implements Resolver.
+func (f FSResolver) Resolve(from, name string) (io.ReadCloser, string, error) {
+
+	dir := "."
+	if from != "" {
+		dir = path.Dir(from)
+	}
+
+	resolved := path.Join(dir, filepath.ToSlash(name))
+
+	fi, err := f.FS.Open(resolved)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fi, resolved, nil
+}
+
+// directiveRef represents a #base or #include line found within a document.
+type directiveRef struct {
+	kind string
+	name string
+	line int
+}
+
+// directiveKind returns the directive kind (#base or #include) named by the
+// provided key, or an empty string if it isn't a directive.
+func directiveKind(key string) string {
+
+	for _, kind := range []string{directiveBase, directiveInclude} {
+		if strings.EqualFold(key, kind) {
+			return kind
+		}
+	}
+
+	return ""
+}
+
+// resolveDirectives parses the files referenced by the provided directives
+// and applies them to the document root. Entries from #include files are
+// appended to the document, whereas #base files are merged into the
+// document without overriding any of its existing values.
+//
+// chain holds the names of the files currently being parsed (with the
+// document's own name last), and is used to detect cycles.
+//
+// In lenient mode, the errors encountered when parsing the referenced files
+// are returned as an ErrorList.
+func resolveDirectives(root *Node, directives []directiveRef, opts ParseOptions, chain []string) (ErrorList, error) {
+
+	var diagnostics ErrorList
+
+	for _, d := range directives {
+
+		if opts.Resolver == nil {
+			return nil, fmt.Errorf("unable to resolve %s \"%s\" on line %d: no Resolver provided", d.kind, d.name, d.line)
+		}
+
+		fi, resolved, err := opts.Resolver.Resolve(opts.Name, d.name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %s \"%s\" on line %d: %w", d.kind, d.name, d.line, err)
+		}
+
+		for _, name := range chain {
+			if name == resolved {
+				fi.Close()
+				return nil, fmt.Errorf("%w: %s", ErrDirectiveCycle, strings.Join(append(chain, resolved), " -> "))
+			}
+		}
+
+		subOpts := opts
+		subOpts.Name = resolved
+
+		tree, err := parseTree(fi, subOpts, append(chain, resolved))
+		fi.Close()
+
+		var list ErrorList
+		if errors.As(err, &list) && tree != nil {
+			diagnostics = append(diagnostics, list...)
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to parse %s \"%s\": %w", d.kind, d.name, err)
+		}
+
+		switch d.kind {
+		case directiveInclude:
+			root.Children = append(root.Children, tree.Children...)
+
+		case directiveBase:
+			mergeBase(root, tree)
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// mergeBase merges the children of base into n. Where n already holds a
+// child with the same key, sections are merged recursively and values are
+// left as they are, otherwise the child from base is appended.
+func mergeBase(n, base *Node) {
+
+	for _, baseChild := range base.Children {
+
+		existing := n.Find(baseChild.Key)
+
+		if existing == nil {
+			n.Children = append(n.Children, baseChild)
+			continue
+		}
+
+		if existing.IsSection() && baseChild.IsSection() {
+			mergeBase(existing, baseChild)
+		}
+	}
+}
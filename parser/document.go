@@ -0,0 +1,734 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	// ErrKeyNotFound is returned (wrapped) when a path passed to a Document
+	// doesn't exist.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// Document is an editable VDF document that retains everything about the
+// source it was parsed from: comments, blank lines, indentation, quoting,
+// conditionals and the order of keys. Writing a Document reproduces the
+// source byte-for-byte, other than the regions changed through Set, Insert,
+// InsertSection and Delete.
+//
+// Entries are addressed by "/" separated paths of keys from the root of the
+// document (e.g. "items_game/paint_kits/0/name"), with keys matched
+// regardless of case (though an exact match is preferred) and the first of
+// any repeated keys used.
+type Document struct {
+
+	// root holds the top level entries, with its closeLeading holding the
+	// text following the last of them
+	root *docEntry
+
+	// encoding is the encoding of the source, and bom whether it began with a
+	// byte order mark, which are both retained when writing
+	encoding Encoding
+	bom      bool
+
+	// newline is the line ending used by the source, which is used for any
+	// entries added
+	newline string
+}
+
+// docEntry is a single entry of a Document, made up of the source text of
+// each of its parts.
+type docEntry struct {
+
+	// leading is the whitespace and comments preceding the key
+	leading string
+
+	key    string
+	keyRaw string
+
+	// mid is the text between the key and its value (or opening brace),
+	// including any conditional following the key
+	mid string
+
+	// value entries: value is the decoded value, and trailing the text
+	// following it up to the end of any conditional
+	value    string
+	valueRaw string
+	trailing string
+
+	// sections: children are the entries within the braces, and
+	// closeLeading the whitespace and comments preceding the closing brace
+	section      bool
+	children     []*docEntry
+	closeLeading string
+}
+
+// ParseDocument parses VDF text into an editable Document. The encoding of
+// the data is detected as by EncodingAuto, and retained when writing.
+func ParseDocument(data []byte) (*Document, error) {
+
+	doc := &Document{
+		encoding: detectEncoding(data),
+	}
+
+	var bom []byte
+
+	switch doc.encoding {
+	case EncodingUTF8:
+		bom = bomUTF8
+
+	case EncodingUTF16LE:
+		bom = bomUTF16LE
+
+	case EncodingUTF16BE:
+		bom = bomUTF16BE
+	}
+
+	doc.bom = bytes.HasPrefix(data, bom)
+
+	r, err := newDecodingReader(bytes.NewReader(data), doc.encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &docParser{
+		lex:     newLexer(bytes.NewReader(content), true),
+		content: content,
+	}
+
+	doc.newline = "\n"
+	if i := bytes.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		doc.newline = "\r\n"
+	}
+
+	doc.root = &docEntry{section: true}
+
+	doc.root.children, doc.root.closeLeading, err = p.parseEntries(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// ReadDocument reads all of the data from r and parses it into an editable
+// Document, as ParseDocument. It is the responsibility of the caller to
+// close r where required.
+func ReadDocument(r io.Reader) (*Document, error) {
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDocument(data)
+}
+
+// Get returns the value at the provided path, and false where the path
+// doesn't exist or is a section.
+func (d *Document) Get(path string) (string, bool) {
+
+	entry := d.find(path)
+	if entry == nil || entry.section {
+		return "", false
+	}
+
+	return entry.value, true
+}
+
+// Set sets the value at the provided path, which is added (along with any
+// sections of the path that don't exist) where it doesn't already exist. An
+// error is returned where the path (or any section of it) is a value rather
+// than a section, or vice versa.
+func (d *Document) Set(path string, value string) error {
+
+	parent, key, err := d.parent(path)
+	if err != nil {
+		return err
+	}
+
+	if i := findEntry(parent, key); i >= 0 {
+		entry := parent.children[i]
+		if entry.section {
+			return fmt.Errorf("unable to set \"%s\": key is a section", path)
+		}
+
+		entry.value = value
+		entry.valueRaw = formatDocString(value, entry.valueRaw)
+
+		return nil
+	}
+
+	d.insert(parent, d.newEntry(parent, key, value, false))
+
+	return nil
+}
+
+// Insert adds a new value at the provided path (after any existing entries
+// of the section), even where the key already exists. Any sections of the
+// path that don't exist are added.
+func (d *Document) Insert(path string, value string) error {
+
+	parent, key, err := d.parent(path)
+	if err != nil {
+		return err
+	}
+
+	d.insert(parent, d.newEntry(parent, key, value, false))
+
+	return nil
+}
+
+// InsertSection adds a new empty section at the provided path (after any
+// existing entries of its parent), even where the key already exists. Any
+// sections of the path that don't exist are added.
+func (d *Document) InsertSection(path string) error {
+
+	parent, key, err := d.parent(path)
+	if err != nil {
+		return err
+	}
+
+	d.insert(parent, d.newEntry(parent, key, "", true))
+
+	return nil
+}
+
+// Delete removes the entry (value or section) at the provided path, along
+// with any comments preceding it, returning ErrKeyNotFound (wrapped) where
+// it doesn't exist.
+func (d *Document) Delete(path string) error {
+
+	keys := splitDocPath(path)
+	if len(keys) == 0 {
+		return fmt.Errorf("invalid path \"%s\"", path)
+	}
+
+	parent := d.root
+	if len(keys) > 1 {
+		parent = d.find(strings.Join(keys[:len(keys)-1], "/"))
+	}
+
+	i := -1
+	if parent != nil && parent.section {
+		i = findEntry(parent, keys[len(keys)-1])
+	}
+
+	if i < 0 {
+		return fmt.Errorf("unable to delete \"%s\": %w", path, ErrKeyNotFound)
+	}
+
+	removed := parent.children[i]
+	parent.children = append(parent.children[:i], parent.children[i+1:]...)
+
+	// any comment on the same line as the removed entry is removed with it,
+	// while any on the line of the preceding entry (held by the removed
+	// entry's leading text) is retained
+	if i < len(parent.children) {
+		next := parent.children[i]
+		next.leading = spliceLeading(removed.leading, next.leading)
+	} else {
+		parent.closeLeading = spliceLeading(removed.leading, parent.closeLeading)
+	}
+
+	return nil
+}
+
+// Tree returns the Document as a tree of Nodes, as parsed by ParseTree.
+func (d *Document) Tree() (*Node, error) {
+	return ParseTree(bytes.NewReader(d.Bytes()))
+}
+
+// Bytes returns the Document as VDF text, in the encoding of its source.
+func (d *Document) Bytes() []byte {
+
+	buf := &bytes.Buffer{}
+
+	for _, child := range d.root.children {
+		child.write(buf)
+	}
+
+	buf.WriteString(d.root.closeLeading)
+
+	var response []byte
+
+	switch d.encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		if d.encoding == EncodingUTF16BE {
+			order = binary.BigEndian
+		}
+
+		if d.bom {
+			response = order.AppendUint16(response, 0xFEFF)
+		}
+
+		for _, unit := range utf16.Encode([]rune(buf.String())) {
+			response = order.AppendUint16(response, unit)
+		}
+
+	default:
+		if d.bom {
+			response = append(response, bomUTF8...)
+		}
+
+		response = append(response, buf.Bytes()...)
+	}
+
+	return response
+}
+
+// WriteTo writes the Document to w as VDF text, implementing io.WriterTo.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+
+	n, err := w.Write(d.Bytes())
+
+	return int64(n), err
+}
+
+// find returns the entry at the provided path, or nil if it doesn't exist.
+func (d *Document) find(path string) *docEntry {
+
+	entry := d.root
+
+	for _, key := range splitDocPath(path) {
+		if !entry.section {
+			return nil
+		}
+
+		i := findEntry(entry, key)
+		if i < 0 {
+			return nil
+		}
+
+		entry = entry.children[i]
+	}
+
+	return entry
+}
+
+// parent returns the section containing the provided path (adding any
+// sections that don't exist), along with the path's final key.
+func (d *Document) parent(path string) (*docEntry, string, error) {
+
+	keys := splitDocPath(path)
+	if len(keys) == 0 {
+		return nil, "", fmt.Errorf("invalid path \"%s\"", path)
+	}
+
+	parent := d.root
+
+	for _, key := range keys[:len(keys)-1] {
+		i := findEntry(parent, key)
+
+		if i < 0 {
+			section := d.newEntry(parent, key, "", true)
+			d.insert(parent, section)
+			parent = section
+			continue
+		}
+
+		if !parent.children[i].section {
+			return nil, "", fmt.Errorf("invalid path \"%s\": \"%s\" is not a section", path, key)
+		}
+
+		parent = parent.children[i]
+	}
+
+	return parent, keys[len(keys)-1], nil
+}
+
+// splitDocPath returns the keys of the provided path.
+func splitDocPath(path string) []string {
+
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+// findEntry returns the index of the first child of the section with the
+// provided key, preferring an exact match to a case-insensitive one, or -1
+// where there is none.
+func findEntry(section *docEntry, key string) int {
+
+	fold := -1
+
+	for i, child := range section.children {
+		if child.key == key {
+			return i
+		}
+
+		if fold < 0 && strings.EqualFold(child.key, key) {
+			fold = i
+		}
+	}
+
+	return fold
+}
+
+// write writes the source of the entry to buf.
+func (e *docEntry) write(buf *bytes.Buffer) {
+
+	buf.WriteString(e.leading)
+	buf.WriteString(e.keyRaw)
+	buf.WriteString(e.mid)
+
+	if !e.section {
+		buf.WriteString(e.valueRaw)
+		buf.WriteString(e.trailing)
+		return
+	}
+
+	buf.WriteByte('{')
+
+	for _, child := range e.children {
+		child.write(buf)
+	}
+
+	buf.WriteString(e.closeLeading)
+	buf.WriteByte('}')
+}
+
+// insert appends the provided entry to the children of the section.
+func (d *Document) insert(section, entry *docEntry) {
+
+	// the closing brace of an empty section written on a single line is moved
+	// onto its own line
+	if section != d.root && !section.inline() && !strings.Contains(section.closeLeading, "\n") {
+		section.closeLeading = d.newline + indentOf(section.leading)
+	}
+
+	section.children = append(section.children, entry)
+}
+
+// inline returns whether the section's entries are written on the same line
+// as its braces, e.g. { "a" "b" }.
+func (e *docEntry) inline() bool {
+
+	if len(e.children) == 0 || strings.Contains(e.closeLeading, "\n") {
+		return false
+	}
+
+	for _, child := range e.children {
+		if strings.Contains(child.leading, "\n") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newEntry returns a new entry (a value, or an empty section) to be added
+// to parent, formatted to match its existing entries.
+func (d *Document) newEntry(parent *docEntry, key, value string, section bool) *docEntry {
+
+	// entries added to an inline section are also inline
+	if parent != d.root && parent.inline() {
+		last := parent.children[len(parent.children)-1]
+
+		entry := &docEntry{
+			leading: " ",
+			key:     key,
+			keyRaw:  formatDocString(key, ""),
+			mid:     " ",
+		}
+
+		if parent.closeLeading == "" {
+			parent.closeLeading = " "
+		}
+
+		if section {
+			entry.section = true
+			entry.closeLeading = " "
+			return entry
+		}
+
+		if !last.section && strings.TrimSpace(last.mid) == "" && last.mid != "" {
+			entry.mid = last.mid
+		}
+
+		entry.value = value
+		entry.valueRaw = formatDocString(value, "")
+
+		return entry
+	}
+
+	indent := ""
+	separator := "\t\t"
+
+	switch {
+
+	// match the last entry of the section
+	case len(parent.children) > 0:
+		last := parent.children[len(parent.children)-1]
+		indent = indentOf(last.leading)
+
+		if !last.section && strings.TrimSpace(last.mid) == "" && last.mid != "" && !strings.Contains(last.mid, "\n") {
+			separator = last.mid
+		}
+
+	// entries are indented one level further than their section
+	case parent != d.root:
+		indent = indentOf(parent.leading) + "\t"
+	}
+
+	leading := d.newline + indent
+
+	// the first entry of an empty document doesn't need a preceding line
+	if parent == d.root && len(parent.children) == 0 && strings.TrimSpace(parent.closeLeading) == "" {
+		leading = indent
+	}
+
+	entry := &docEntry{
+		leading: leading,
+		key:     key,
+		keyRaw:  formatDocString(key, ""),
+	}
+
+	if !section {
+		entry.mid = separator
+		entry.value = value
+		entry.valueRaw = formatDocString(value, "")
+		return entry
+	}
+
+	entry.section = true
+	entry.mid = d.newline + indent
+	entry.closeLeading = d.newline + indent
+
+	return entry
+}
+
+// indentOf returns the indentation of the line ending the provided leading
+// text, i.e. any whitespace following its last line break.
+func indentOf(leading string) string {
+
+	last := leading[strings.LastIndexByte(leading, '\n')+1:]
+
+	if strings.TrimLeft(last, " \t") != "" {
+		return ""
+	}
+
+	return last
+}
+
+// spliceLeading returns the leading text of the entry following a removed
+// entry, made up of the text on the line of the entry preceding the removed
+// one (from the removed entry's leading text), followed by the text of
+// following (the leading text of the next entry) after the removed entry's
+// line.
+//
+// Where following doesn't begin a new line (i.e. the next entry is on the
+// same line as the removed one), the removed entry's leading text is used
+// in its place.
+func spliceLeading(removed, following string) string {
+
+	i := lineBreak(following)
+	if i < 0 {
+		return removed
+	}
+
+	if j := lineBreak(removed); j >= 0 {
+		removed = removed[:j]
+	}
+
+	return removed + following[i:]
+}
+
+// lineBreak returns the index of the first line break of the provided text
+// (including any carriage return preceding it), or -1 where there is none.
+func lineBreak(s string) int {
+
+	i := strings.IndexByte(s, '\n')
+
+	if i > 0 && s[i-1] == '\r' {
+		i--
+	}
+
+	return i
+}
+
+// formatDocString returns the source for the provided string, which is
+// quoted (and escaped) unless the string it replaces (previous) was
+// unquoted and s can also be written without quotes.
+func formatDocString(s, previous string) string {
+
+	if previous != "" && !strings.HasPrefix(previous, "\"") && s != "" && !strings.ContainsAny(s, " \t\r\n\v\f\"{}[]\\/") {
+		return s
+	}
+
+	return "\"" + escaper.Replace(s) + "\""
+}
+
+// docParser parses VDF text into the entries of a Document.
+type docParser struct {
+	lex     *lexer
+	content []byte
+
+	// last is the end offset of the last token consumed
+	last int64
+
+	// pending holds a token read ahead but not yet consumed
+	pending *docToken
+}
+
+// docToken is a token along with its offsets within the content.
+type docToken struct {
+	token
+	start, end int64
+}
+
+// next consumes and returns the next token.
+func (p *docParser) next() (docToken, error) {
+
+	if p.pending != nil {
+		t := *p.pending
+		p.pending = nil
+		return t, nil
+	}
+
+	t, err := p.lex.next()
+	if err != nil {
+		return docToken{}, p.fail(err)
+	}
+
+	return docToken{token: t, start: t.offset, end: p.lex.offset()}, nil
+}
+
+// raw returns the source text between the provided offsets.
+func (p *docParser) raw(from, to int64) string {
+	return string(p.content[from:to])
+}
+
+// fail populates the snippet of a ParseError.
+func (p *docParser) fail(err error) error {
+
+	if parseErr, ok := err.(*ParseError); ok {
+		parseErr.Snippet = p.lex.snippet(parseErr.lineOffset)
+	}
+
+	return err
+}
+
+// parseEntries parses the entries of a section (or the root where open is
+// nil), up to and including its closing brace, returning them along with the
+// text preceding the closing brace (or end of data).
+func (p *docParser) parseEntries(open *docToken) ([]*docEntry, string, error) {
+
+	var entries []*docEntry
+
+	for {
+		t, err := p.next()
+		if err != nil {
+			return nil, "", err
+		}
+
+		switch t.typ {
+		case tokenEOF:
+			if open != nil {
+				return nil, "", p.fail(newParseError(t.token, fmt.Sprintf("unexpected end of data, section \"%s\" opened on line %d is not closed", open.value, open.line), "'}'"))
+			}
+
+			return entries, p.raw(p.last, t.start), nil
+
+		case tokenClose:
+			if open == nil {
+				return nil, "", p.fail(newUnexpectedError(t.token, "key"))
+			}
+
+			closeLeading := p.raw(p.last, t.start)
+			p.last = t.end
+
+			return entries, closeLeading, nil
+
+		case tokenOpen, tokenConditional:
+			return nil, "", p.fail(newUnexpectedError(t.token, "key", "'}'"))
+		}
+
+		entry, err := p.parseEntry(t)
+		if err != nil {
+			return nil, "", err
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+// parseEntry parses the remainder of an entry following its key.
+func (p *docParser) parseEntry(key docToken) (*docEntry, error) {
+
+	entry := &docEntry{
+		leading: p.raw(p.last, key.start),
+		key:     key.value,
+		keyRaw:  p.raw(key.start, key.end),
+	}
+
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	conditional := t.typ == tokenConditional
+	if conditional {
+		if t, err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	entry.mid = p.raw(key.end, t.start)
+
+	switch t.typ {
+
+	// section: children follow
+	case tokenOpen:
+		entry.section = true
+		p.last = t.end
+
+		entry.children, entry.closeLeading, err = p.parseEntries(&key)
+		return entry, err
+
+	// data: value may be followed by a conditional
+	case tokenString:
+		entry.value = t.value
+		entry.valueRaw = p.raw(t.start, t.end)
+		p.last = t.end
+
+		if conditional {
+			return entry, nil
+		}
+
+		next, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if next.typ != tokenConditional {
+			p.pending = &next
+			return entry, nil
+		}
+
+		entry.trailing = p.raw(t.end, next.end)
+		p.last = next.end
+
+		return entry, nil
+	}
+
+	unexpected := newUnexpectedError(t.token, "value", "'{'")
+	unexpected.Msg += fmt.Sprintf(" following key \"%s\"", key.value)
+
+	return nil, p.fail(unexpected)
+}
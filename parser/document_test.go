@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestDocumentDelete(t *testing.T) {
+
+	for _, test := range []struct {
+		name     string
+		source   string
+		path     string
+		expected string
+	}{
+		{
+			name:     "comment on preceding line retained",
+			source:   "\"r\"\n{\n\t\"a\"\t\t\"1\" // about a\n\t\"b\"\t\t\"2\"\n}\n",
+			path:     "r/b",
+			expected: "\"r\"\n{\n\t\"a\"\t\t\"1\" // about a\n}\n",
+		},
+		{
+			name:     "comment on preceding line retained before next entry",
+			source:   "\"r\"\n{\n\t\"a\"\t\t\"1\" // about a\n\t\"b\"\t\t\"2\"\n\t\"c\"\t\t\"3\"\n}\n",
+			path:     "r/b",
+			expected: "\"r\"\n{\n\t\"a\"\t\t\"1\" // about a\n\t\"c\"\t\t\"3\"\n}\n",
+		},
+		{
+			name:     "comment on removed line removed",
+			source:   "\"r\"\n{\n\t\"a\"\t\t\"1\" // about a\n\t\"b\"\t\t\"2\" // about b\n\t\"c\"\t\t\"3\"\n}\n",
+			path:     "r/b",
+			expected: "\"r\"\n{\n\t\"a\"\t\t\"1\" // about a\n\t\"c\"\t\t\"3\"\n}\n",
+		},
+		{
+			name:     "comment on opening brace retained",
+			source:   "\"r\"\n{ // about r\n\t\"a\"\t\t\"1\"\n\t\"b\"\t\t\"2\"\n}\n",
+			path:     "r/a",
+			expected: "\"r\"\n{ // about r\n\t\"b\"\t\t\"2\"\n}\n",
+		},
+		{
+			name:     "crlf",
+			source:   "\"r\"\r\n{\r\n\t\"a\"\t\t\"1\" // about a\r\n\t\"b\"\t\t\"2\"\r\n}\r\n",
+			path:     "r/b",
+			expected: "\"r\"\r\n{\r\n\t\"a\"\t\t\"1\" // about a\r\n}\r\n",
+		},
+		{
+			name:     "inline section",
+			source:   "\"r\" { \"a\" \"1\" \"b\" \"2\" }\n",
+			path:     "r/b",
+			expected: "\"r\" { \"a\" \"1\" }\n",
+		},
+		{
+			name:     "next entry on the same line",
+			source:   "\"r\"\n{\n\t\"a\" \"1\"\n\t\"b\" \"2\" \"c\" \"3\"\n}\n",
+			path:     "r/b",
+			expected: "\"r\"\n{\n\t\"a\" \"1\"\n\t\"c\" \"3\"\n}\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			doc, err := ParseDocument([]byte(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := doc.Delete(test.path); err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := string(doc.Bytes()); actual != test.expected {
+				t.Errorf("Bytes = %q, expected %q", actual, test.expected)
+			}
+
+			if _, err := doc.Tree(); err != nil {
+				t.Errorf("Tree: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+)
+
+// DuplicatePolicy determines how entries sharing a key within the same
+// section are handled when converting a tree into a map.
+type DuplicatePolicy int
+
+const (
+	// DuplicatesMerge merges repeated sections into one, with the entries of
+	// each added in order, while the last of any repeated values wins. Where
+	// a key is used for both a section and a value, the last wins. This is
+	// the default, and the behaviour of Node.Map.
+	DuplicatesMerge DuplicatePolicy = iota
+
+	// DuplicatesFirstWins keeps only the first entry with a given key.
+	DuplicatesFirstWins
+
+	// DuplicatesLastWins keeps only the last entry with a given key, with
+	// repeated sections replacing rather than merging with one another.
+	DuplicatesLastWins
+
+	// DuplicatesList collects every entry with a given key into a
+	// []interface{} (in order), where the key is repeated. Keys that aren't
+	// repeated hold their value as normal.
+	DuplicatesList
+
+	// DuplicatesError returns a *ParseError for the second entry with a
+	// given key.
+	DuplicatesError
+)
+
+// String returns the name of the policy.
+func (p DuplicatePolicy) String() string {
+
+	switch p {
+	case DuplicatesMerge:
+		return "merge"
+
+	case DuplicatesFirstWins:
+		return "first-wins"
+
+	case DuplicatesLastWins:
+		return "last-wins"
+
+	case DuplicatesList:
+		return "list"
+
+	case DuplicatesError:
+		return "error"
+	}
+
+	return fmt.Sprintf("DuplicatePolicy(%d)", int(p))
+}
+
+// ParseReaderWithOptions parses the VDF data read from r into a map of type
+// map[string]interface{} in the same way as ParseReader, applying the
+// provided options (including its duplicate key policy). It is the
+// responsibility of the caller to close r where required.
+//
+// Where opts.Lenient is set, the map of the recovered tree is returned
+// alongside the ErrorList of syntax errors, as ParseTreeWithOptions.
+func ParseReaderWithOptions(r io.Reader, opts ParseOptions) (map[string]interface{}, error) {
+
+	tree, err := ParseTreeWithOptions(r, opts)
+	if tree == nil {
+		return nil, err
+	}
+
+	m, mapErr := tree.MapWithOptions(opts)
+	if mapErr != nil {
+		return nil, mapErr
+	}
+
+	return m, err
+}
+
+// MapWithOptions converts the children of the Node into a map in the same
+// way as Map, but with repeated keys handled according to the Duplicates,
+// SectionDuplicates and FoldKeys of the provided options (other options are
+// unused, other than Name, which is used within any error). Where the policy is
+// DuplicatesError, the first repeated key is returned as a *ParseError.
+func (n *Node) MapWithOptions(opts ParseOptions) (map[string]interface{}, error) {
+
+	c := &mapConverter{opts: opts}
+
+	return c.convert(n.Children, "", opts.Duplicates)
+}
+
+// mapConverter converts trees of Nodes into maps, applying a duplicate key
+// policy.
+type mapConverter struct {
+	opts ParseOptions
+}
+
+// policy returns the duplicate key policy for the section at the provided
+// path, which otherwise inherits the policy of its parent.
+func (c *mapConverter) policy(path string, parent DuplicatePolicy) DuplicatePolicy {
+
+	if policy, ok := c.opts.SectionDuplicates[path]; ok {
+		return policy
+	}
+
+	return parent
+}
+
+// convert converts the provided nodes, which are the entries of the section
+// at the provided path, into a map.
+func (c *mapConverter) convert(nodes []*Node, path string, parent DuplicatePolicy) (map[string]interface{}, error) {
+
+	policy := c.policy(path, parent)
+
+	// entries are grouped by key (or by key regardless of case where
+	// FoldKeys is set), retaining the order keys first appear in
+	var keys []string
+	groups := make(map[string][]*Node, len(nodes))
+
+	for _, node := range nodes {
+		key := node.Key
+		if c.opts.FoldKeys {
+			key = foldKey(key)
+		}
+
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		groups[key] = append(groups[key], node)
+	}
+
+	response := make(map[string]interface{}, len(keys))
+
+	for _, groupKey := range keys {
+		group := groups[groupKey]
+
+		// the casing of the first entry is retained
+		key := group[0].Key
+		childPath := joinPath(path, key)
+
+		if len(group) > 1 && policy == DuplicatesError {
+			return nil, &ParseError{
+				Name:   c.opts.Name,
+				Line:   group[1].Line,
+				Column: group[1].Column,
+				Msg:    fmt.Sprintf("duplicate key \"%s\" (first defined on line %d)", childPath, group[0].Line),
+			}
+		}
+
+		switch policy {
+		case DuplicatesFirstWins:
+			group = group[:1]
+
+		case DuplicatesLastWins:
+			group = group[len(group)-1:]
+
+		case DuplicatesMerge:
+			group = mergeGroup(group)
+		}
+
+		if len(group) == 1 || policy == DuplicatesMerge {
+			value, err := c.value(group, childPath, policy)
+			if err != nil {
+				return nil, err
+			}
+
+			response[key] = value
+			continue
+		}
+
+		list := make([]interface{}, len(group))
+
+		for i := range group {
+			value, err := c.value(group[i:i+1], childPath, policy)
+			if err != nil {
+				return nil, err
+			}
+
+			list[i] = value
+		}
+
+		response[key] = list
+	}
+
+	return response, nil
+}
+
+// value converts the provided nodes (sharing a key) into a single value,
+// with sections having their children combined.
+func (c *mapConverter) value(group []*Node, path string, policy DuplicatePolicy) (interface{}, error) {
+
+	if !group[0].IsSection() {
+		return group[0].Value, nil
+	}
+
+	if len(group) == 1 {
+		return c.convert(group[0].Children, path, policy)
+	}
+
+	var children []*Node
+	for _, node := range group {
+		children = append(children, node.Children...)
+	}
+
+	return c.convert(children, path, policy)
+}
+
+// mergeGroup returns the nodes of a group of entries sharing a key that
+// make up the value under DuplicatesMerge: the last value, or the sections
+// following it.
+func mergeGroup(group []*Node) []*Node {
+
+	last := len(group) - 1
+	if !group[last].IsSection() {
+		return group[last:]
+	}
+
+	start := last
+	for start > 0 && group[start-1].IsSection() {
+		start--
+	}
+
+	return group[start:]
+}
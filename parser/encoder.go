@@ -0,0 +1,322 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// escaper replaces the characters that can't appear verbatim within a
+	// quoted string with their escape sequences.
+	escaper = strings.NewReplacer(
+		"\\", "\\\\",
+		"\"", "\\\"",
+		"\n", "\\n",
+		"\t", "\\t",
+	)
+)
+
+// Marshal returns the VDF (KeyValues text) encoding of v. See Encoder.Encode
+// for the supported types of v.
+func Marshal(v interface{}) ([]byte, error) {
+
+	buf := &bytes.Buffer{}
+
+	if err := NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Encoder writes VDF (KeyValues text) to an output stream.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+// Encode writes the VDF encoding of v to the stream, where v is one of:
+//
+//   - *Node: the children of the Node are written as the top level entries
+//     of the document (i.e. the root Node returned by ParseTree)
+//   - map: the entries of the map are written as the top level entries of
+//     the document (e.g. the map returned by Parse), with keys written in
+//     sorted order
+//   - struct: the fields of the struct are written as the top level entries
+//     of the document, using the same `vdf` tags as Unmarshal, with fields
+//     tagged omitempty skipped when they hold their zero value
+//
+// Values are written with tab indentation and any quotes, backslashes, tabs
+// and newlines escaped.
+func (e *Encoder) Encode(v interface{}) error {
+
+	w := bufio.NewWriter(e.w)
+
+	var err error
+
+	if n, ok := v.(*Node); ok {
+		if n == nil {
+			return fmt.Errorf("unable to encode nil value")
+		}
+
+		err = writeNodes(w, n.Children, 0)
+	} else {
+		err = writeEntries(w, reflect.ValueOf(v), 0)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// writeNodes writes the provided nodes at the provided depth of indentation.
+func writeNodes(w *bufio.Writer, nodes []*Node, depth int) error {
+
+	for _, node := range nodes {
+
+		if !node.IsSection() {
+			writeValue(w, node.Key, node.Value, node.Condition, depth)
+			continue
+		}
+
+		writeSectionOpen(w, node.Key, node.Condition, depth)
+
+		if err := writeNodes(w, node.Children, depth+1); err != nil {
+			return err
+		}
+
+		writeSectionClose(w, depth)
+	}
+
+	return nil
+}
+
+// writeEntries writes the entries of the provided map or struct at the
+// provided depth of indentation.
+func writeEntries(w *bufio.Writer, rv reflect.Value, depth int) error {
+
+	rv = indirect(rv)
+
+	switch rv.Kind() {
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]reflect.Value, rv.Len())
+
+		for iter := rv.MapRange(); iter.Next(); {
+			key := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, key)
+			values[key] = iter.Value()
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := writeEntry(w, key, values[key], depth); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case reflect.Struct:
+		t := rv.Type()
+
+		for i := 0; i < t.NumField(); i++ {
+
+			key, opts, skip := parseTag(t.Field(i))
+			if skip {
+				continue
+			}
+
+			fv := rv.Field(i)
+			if opts.omitEmpty && fv.IsZero() {
+				continue
+			}
+
+			if err := writeEntry(w, key, fv, depth); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if !rv.IsValid() {
+		return fmt.Errorf("unable to encode nil value")
+	}
+
+	return fmt.Errorf("unable to encode value of type %s", rv.Type())
+}
+
+// writeEntry writes the provided value under the provided key, as either a
+// key value pair or a section, at the provided depth of indentation.
+func writeEntry(w *bufio.Writer, key string, rv reflect.Value, depth int) error {
+
+	rv = indirect(rv)
+
+	// nil values are omitted
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if n, ok := rv.Addr().Interface().(*Node); ok {
+		if !n.IsSection() {
+			writeValue(w, key, n.Value, n.Condition, depth)
+			return nil
+		}
+
+		writeSectionOpen(w, key, n.Condition, depth)
+
+		if err := writeNodes(w, n.Children, depth+1); err != nil {
+			return err
+		}
+
+		writeSectionClose(w, depth)
+		return nil
+	}
+
+	if m, ok := rv.Addr().Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return err
+		}
+
+		writeValue(w, key, string(text), "", depth)
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Map, reflect.Struct:
+		writeSectionOpen(w, key, "", depth)
+
+		if err := writeEntries(w, rv, depth+1); err != nil {
+			return err
+		}
+
+		writeSectionClose(w, depth)
+
+	// slices are written as repeated keys
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := writeEntry(w, key, rv.Index(i), depth); err != nil {
+				return err
+			}
+		}
+
+	case reflect.String:
+		writeValue(w, key, rv.String(), "", depth)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeValue(w, key, strconv.FormatInt(rv.Int(), 10), "", depth)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		writeValue(w, key, strconv.FormatUint(rv.Uint(), 10), "", depth)
+
+	case reflect.Float32, reflect.Float64:
+		writeValue(w, key, strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), "", depth)
+
+	case reflect.Bool:
+		if rv.Bool() {
+			writeValue(w, key, "1", "", depth)
+		} else {
+			writeValue(w, key, "0", "", depth)
+		}
+
+	default:
+		return fmt.Errorf("unable to encode value of type %s at key %s", rv.Type(), key)
+	}
+
+	return nil
+}
+
+// indirect dereferences the provided value through any pointers and
+// interfaces, returning an invalid reflect.Value where a nil is reached. The
+// returned value is always addressable.
+func indirect(rv reflect.Value) reflect.Value {
+
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.IsValid() && !rv.CanAddr() {
+		addressable := reflect.New(rv.Type()).Elem()
+		addressable.Set(rv)
+		rv = addressable
+	}
+
+	return rv
+}
+
+// writeValue writes a single key value pair line, followed by its
+// conditional where present.
+func writeValue(w *bufio.Writer, key, value, condition string, depth int) {
+	writeIndent(w, depth)
+	writeQuoted(w, key)
+	w.WriteString("\t\t")
+	writeQuoted(w, value)
+	writeCondition(w, condition)
+	w.WriteByte('\n')
+}
+
+// writeSectionOpen writes the key (and conditional where present) and
+// opening brace of a section.
+func writeSectionOpen(w *bufio.Writer, key, condition string, depth int) {
+	writeIndent(w, depth)
+	writeQuoted(w, key)
+	writeCondition(w, condition)
+	w.WriteByte('\n')
+	writeIndent(w, depth)
+	w.WriteString("{\n")
+}
+
+// writeSectionClose writes the closing brace of a section.
+func writeSectionClose(w *bufio.Writer, depth int) {
+	writeIndent(w, depth)
+	w.WriteString("}\n")
+}
+
+// writeIndent writes depth number of tabs.
+func writeIndent(w *bufio.Writer, depth int) {
+	for i := 0; i < depth; i++ {
+		w.WriteByte('\t')
+	}
+}
+
+// writeCondition writes the provided conditional within brackets, if it
+// isn't empty.
+func writeCondition(w *bufio.Writer, condition string) {
+
+	if condition == "" {
+		return
+	}
+
+	w.WriteString(" [")
+	w.WriteString(condition)
+	w.WriteByte(']')
+}
+
+// writeQuoted writes s as a quoted and escaped string.
+func writeQuoted(w *bufio.Writer, s string) {
+	w.WriteByte('"')
+	escaper.WriteString(w, s)
+	w.WriteByte('"')
+}
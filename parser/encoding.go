@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding represents the character encoding of VDF text data.
+type Encoding int
+
+const (
+	// EncodingAuto detects the encoding from the byte order mark at the start
+	// of the data, falling back to inspecting the first character where there
+	// isn't one, and otherwise assuming UTF-8.
+	EncodingAuto Encoding = iota
+
+	// EncodingUTF8 is UTF-8 (with or without a byte order mark)
+	EncodingUTF8
+
+	// EncodingUTF16LE is little endian UTF-16 (with or without a byte order
+	// mark), as commonly used by the csgo_<language>.txt files
+	EncodingUTF16LE
+
+	// EncodingUTF16BE is big endian UTF-16 (with or without a byte order
+	// mark)
+	EncodingUTF16BE
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// String returns the name of the Encoding.
+func (e Encoding) String() string {
+
+	switch e {
+	case EncodingAuto:
+		return "auto"
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	}
+
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// newDecodingReader returns a reader producing the UTF-8 form of the data
+// read from r (which is in the provided encoding), with any byte order mark
+// removed.
+func newDecodingReader(r io.Reader, encoding Encoding) (io.Reader, error) {
+
+	br := bufio.NewReader(r)
+
+	// data shorter than a byte order mark is valid, so io.EOF is ignored
+	start, err := br.Peek(3)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("unable to read data: %w", err)
+	}
+
+	if encoding == EncodingAuto {
+		encoding = detectEncoding(start)
+	}
+
+	var bom []byte
+
+	switch encoding {
+	case EncodingUTF8:
+		bom = bomUTF8
+
+	case EncodingUTF16LE:
+		bom = bomUTF16LE
+
+	case EncodingUTF16BE:
+		bom = bomUTF16BE
+
+	default:
+		return nil, fmt.Errorf("unsupported encoding %s", encoding)
+	}
+
+	// skip byte order mark
+	if len(start) >= len(bom) && string(start[:len(bom)]) == string(bom) {
+		br.Discard(len(bom))
+	}
+
+	switch encoding {
+	case EncodingUTF16LE:
+		return &utf16Reader{r: br, order: binary.LittleEndian}, nil
+
+	case EncodingUTF16BE:
+		return &utf16Reader{r: br, order: binary.BigEndian}, nil
+	}
+
+	return br, nil
+}
+
+// detectEncoding identifies the encoding of data beginning with the provided
+// bytes.
+func detectEncoding(start []byte) Encoding {
+
+	switch {
+	case len(start) >= 3 && string(start[:3]) == string(bomUTF8):
+		return EncodingUTF8
+
+	case len(start) >= 2 && string(start[:2]) == string(bomUTF16LE):
+		return EncodingUTF16LE
+
+	case len(start) >= 2 && string(start[:2]) == string(bomUTF16BE):
+		return EncodingUTF16BE
+
+	// without a byte order mark, an ASCII first character (e.g. a quote)
+	// encoded as UTF-16 has a null byte as either its second or first byte
+	case len(start) >= 2 && start[0] != 0 && start[1] == 0:
+		return EncodingUTF16LE
+
+	case len(start) >= 2 && start[0] == 0 && start[1] != 0:
+		return EncodingUTF16BE
+	}
+
+	return EncodingUTF8
+}
+
+// utf16Reader converts UTF-16 data into UTF-8.
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+
+	// pending holds converted data not yet read
+	pending []byte
+
+	// unit holds a code unit that has been read but not yet converted
+	unit    uint16
+	hasUnit bool
+
+	err error
+}
+
+// Read implements io.Reader.
+func (u *utf16Reader) Read(p []byte) (int, error) {
+
+	for len(u.pending) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+
+		u.fill()
+	}
+
+	n := copy(p, u.pending)
+	u.pending = u.pending[n:]
+
+	return n, nil
+}
+
+// fill converts the next block of code units into pending.
+func (u *utf16Reader) fill() {
+
+	u.pending = u.pending[:0]
+
+	for len(u.pending) < 4096 {
+
+		unit, ok := u.readUnit()
+		if !ok {
+			return
+		}
+
+		r := rune(unit)
+
+		// surrogate pairs are made up of two code units
+		if utf16.IsSurrogate(r) {
+			low, ok := u.readUnit()
+			if !ok {
+				u.pending = utf8.AppendRune(u.pending, utf8.RuneError)
+				return
+			}
+
+			r = utf16.DecodeRune(r, rune(low))
+
+			// if not a valid pair, the second code unit is converted alone
+			if r == utf8.RuneError {
+				u.unit, u.hasUnit = low, true
+			}
+		}
+
+		u.pending = utf8.AppendRune(u.pending, r)
+	}
+}
+
+// readUnit reads the next code unit, returning false (and recording the
+// error) where it can't be read.
+func (u *utf16Reader) readUnit() (uint16, bool) {
+
+	if u.hasUnit {
+		u.hasUnit = false
+		return u.unit, true
+	}
+
+	var b [2]byte
+
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("unable to decode UTF-16 data: odd number of bytes")
+		}
+
+		u.err = err
+		return 0, false
+	}
+
+	return u.order.Uint16(b[:]), true
+}
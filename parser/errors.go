@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a syntax error within VDF text data.
+type ParseError struct {
+
+	// Name is the name of the document the error occurred in (see
+	// ParseOptions.Name), if provided.
+	Name string
+
+	// Line and Column are the 1-based position of the error within the
+	// source.
+	Line   int
+	Column int
+
+	// Token is a description of the offending token, e.g. "'}'" or
+	// "string \"foo\"".
+	Token string
+
+	// Expected describes the tokens that were expected in place of Token
+	// (where known), e.g. "key" or "'}'".
+	Expected []string
+
+	// Snippet is the line of the source the error occurred on.
+	Snippet string
+
+	// Msg is a description of the error.
+	Msg string
+
+	// lineOffset is the offset of the start of the line within the source,
+	// used to populate Snippet
+	lineOffset int64
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+
+	msg := fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+
+	if e.Name != "" {
+		msg = e.Name + ": " + msg
+	}
+
+	if len(e.Expected) > 0 {
+		msg += fmt.Sprintf(" (expected %s)", strings.Join(e.Expected, " or "))
+	}
+
+	return msg
+}
+
+// ErrorList is a list of ParseErrors, as returned when parsing in lenient
+// mode (see ParseOptions.Lenient).
+type ErrorList []*ParseError
+
+// Error implements error.
+func (l ErrorList) Error() string {
+
+	switch len(l) {
+	case 0:
+		return "no errors"
+
+	case 1:
+		return l[0].Error()
+	}
+
+	return fmt.Sprintf("%s (and %d more errors)", l[0].Error(), len(l)-1)
+}
+
+// As finds the first ParseError of the list that matches target, as
+// errors.As, allowing each to be matched through errors.As (including prior
+// to Go 1.20, which doesn't support Unwrap() []error).
+func (l ErrorList) As(target interface{}) bool {
+
+	for _, err := range l {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unwrap returns the ParseErrors of the list, allowing each to be matched
+// through errors.Is and errors.As (from Go 1.20).
+func (l ErrorList) Unwrap() []error {
+
+	response := make([]error, len(l))
+
+	for i, err := range l {
+		response[i] = err
+	}
+
+	return response
+}
+
+// newParseError returns a ParseError at the position of the provided token.
+func newParseError(t token, msg string, expected ...string) *ParseError {
+	return &ParseError{
+		Line:       t.line,
+		Column:     t.column,
+		Token:      t.String(),
+		Expected:   expected,
+		Msg:        msg,
+		lineOffset: t.lineOffset,
+	}
+}
+
+// newUnexpectedError returns a ParseError describing the provided token as
+// unexpected.
+func newUnexpectedError(t token, expected ...string) *ParseError {
+	return newParseError(t, fmt.Sprintf("unexpected %s", t), expected...)
+}
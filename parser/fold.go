@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+)
+
+// FindFold returns the first child of the Node whose key matches the
+// provided key case-insensitively (as keys are treated by the engine), or
+// nil if no such child exists.
+func (n *Node) FindFold(key string) *Node {
+
+	for _, child := range n.Children {
+		if strings.EqualFold(child.Key, key) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// FindAllFold returns every child of the Node whose key matches the provided
+// key case-insensitively, in the order they appear.
+func (n *Node) FindAllFold(key string) []*Node {
+
+	var response []*Node
+
+	for _, child := range n.Children {
+		if strings.EqualFold(child.Key, key) {
+			response = append(response, child)
+		}
+	}
+
+	return response
+}
+
+// LookupFold traverses the provided map (as returned by Parse) through the
+// provided path of keys, matching each key case-insensitively, and returns
+// the value at the end of the path. It returns false where any key can't be
+// found, or any value but the last isn't a section.
+//
+// At each level an exact match is preferred, otherwise where several keys
+// differ only in case, the lowest sorted key is used.
+func LookupFold(m map[string]interface{}, path ...string) (interface{}, bool) {
+
+	var value interface{} = m
+
+	for _, key := range path {
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = lookupFold(section, key)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// lookupFold returns the value of the provided key within m, matched
+// case-insensitively as described by LookupFold.
+func lookupFold(m map[string]interface{}, key string) (interface{}, bool) {
+
+	match, ok := matchFold(m, key)
+	if !ok {
+		return nil, false
+	}
+
+	return m[match], true
+}
+
+// matchFold returns the key of m matching the provided key
+// case-insensitively, preferring an exact match, otherwise the lowest sorted
+// of the keys that differ only in case, and whether any such key exists.
+func matchFold(m map[string]interface{}, key string) (string, bool) {
+
+	if _, ok := m[key]; ok {
+		return key, true
+	}
+
+	match, found := "", false
+
+	for k := range m {
+		if strings.EqualFold(k, key) && (!found || k < match) {
+			match, found = k, true
+		}
+	}
+
+	return match, found
+}
+
+// foldKey returns the key used to group keys that differ only in case.
+func foldKey(key string) string {
+	return strings.ToLower(key)
+}
@@ -0,0 +1,573 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// lexerBufferSize is the initial size of the lexer's buffer, which grows
+	// where a single token doesn't fit within it
+	lexerBufferSize = 64 * 1024
+
+	// maxInternLength and maxInterned limit the strings that are interned to
+	// short strings (i.e. keys and common values such as "1"), and the number
+	// of strings held, respectively
+	maxInternLength = 32
+	maxInterned     = 8192
+)
+
+// tokenType represents the type of a lexical token within VDF data.
+type tokenType int
+
+const (
+	// tokenEOF represents the end of the data
+	tokenEOF tokenType = iota
+
+	// tokenString represents a quoted or unquoted string (i.e. a key or
+	// value)
+	tokenString
+
+	// tokenOpen represents the opening brace of a section
+	tokenOpen
+
+	// tokenClose represents the closing brace of a section
+	tokenClose
+
+	// tokenConditional represents a conditional, e.g. [$WIN32]
+	tokenConditional
+)
+
+// token represents a single lexical token, along with its position within
+// the data.
+type token struct {
+	typ tokenType
+
+	// value is the decoded string (tokenString) or expression without its
+	// brackets (tokenConditional)
+	value  string
+	quoted bool
+
+	line   int
+	column int
+
+	// lineOffset is the offset within the data of the start of the line the
+	// token begins on, and offset the offset of the token itself
+	lineOffset int64
+	offset     int64
+}
+
+// String returns a description of the token for use within errors.
+func (t token) String() string {
+
+	switch t.typ {
+	case tokenEOF:
+		return "end of data"
+
+	case tokenOpen:
+		return "'{'"
+
+	case tokenClose:
+		return "'}'"
+
+	case tokenConditional:
+		return fmt.Sprintf("conditional [%s]", t.value)
+	}
+
+	return fmt.Sprintf("string \"%s\"", t.value)
+}
+
+// lexer splits VDF data into tokens. Whitespace (including line breaks) and
+// comments between tokens are discarded.
+//
+// Data is read into a buffer that tokens are sliced from directly, with a
+// string only allocated for the token's final value (where it hasn't already
+// been interned). Escape sequences are decoded into a reusable scratch
+// buffer.
+type lexer struct {
+	r       io.Reader
+	escapes bool
+
+	// buf holds the buffered data, with base being the offset of buf[0]
+	// within the data as a whole
+	buf  []byte
+	base int64
+
+	// pos is the position of the next byte to read within buf, and mark the
+	// position before which data can be discarded when refilling buf
+	pos  int
+	mark int
+
+	// lineStart is the position of the start of the current line within buf
+	lineStart int
+	lineNum   int
+
+	// eof is set once all data has been read, whereas err holds any error
+	// reading the data (other than io.EOF)
+	eof bool
+	err error
+
+	// interned holds previously seen strings, which are reused rather than
+	// allocated again
+	interned map[string]string
+	scratch  []byte
+
+	// peeked holds the next token (and any error reading it) where
+	// hasPeeked is set, i.e. it has been peeked but not yet consumed. The
+	// token is held by value so that peeking doesn't allocate
+	peeked    token
+	peekedErr error
+	hasPeeked bool
+}
+
+// newLexer returns a lexer reading from r. Where escapes is true, escape
+// sequences within quoted strings are decoded.
+func newLexer(r io.Reader, escapes bool) *lexer {
+	return &lexer{
+		r:        r,
+		escapes:  escapes,
+		buf:      make([]byte, 0, lexerBufferSize),
+		lineNum:  1,
+		interned: make(map[string]string),
+	}
+}
+
+// peek returns the next token without consuming it.
+func (l *lexer) peek() (token, error) {
+
+	if l.hasPeeked {
+		return l.peeked, l.peekedErr
+	}
+
+	t, err := l.next()
+
+	l.peeked = t
+	l.peekedErr = err
+	l.hasPeeked = true
+
+	return t, err
+}
+
+// conditional consumes and returns the expression of the next token if it
+// is a conditional, otherwise an empty string is returned.
+func (l *lexer) conditional() (string, error) {
+
+	t, err := l.peek()
+	if t.typ != tokenConditional {
+		return "", nil
+	}
+
+	l.hasPeeked = false
+
+	return t.value, err
+}
+
+// next consumes and returns the next token.
+func (l *lexer) next() (token, error) {
+
+	if l.hasPeeked {
+		l.hasPeeked = false
+		return l.peeked, l.peekedErr
+	}
+
+	if !l.skip() {
+		if l.err != nil {
+			return token{}, l.err
+		}
+
+		return l.start(tokenEOF), nil
+	}
+
+	// the line of the token is retained for use within errors
+	l.mark = l.lineStart
+
+	switch l.buf[l.pos] {
+	case '{':
+		t := l.start(tokenOpen)
+		l.pos++
+		return t, nil
+
+	case '}':
+		t := l.start(tokenClose)
+		l.pos++
+		return t, nil
+
+	case '"':
+		return l.readQuoted(l.start(tokenString))
+
+	case '[':
+		return l.readConditional(l.start(tokenConditional))
+	}
+
+	return l.readUnquoted(l.start(tokenString)), nil
+}
+
+// start returns a token of the provided type at the current position.
+func (l *lexer) start(typ tokenType) token {
+	return token{
+		typ:        typ,
+		line:       l.lineNum,
+		column:     l.pos - l.lineStart + 1,
+		lineOffset: l.base + int64(l.lineStart),
+		offset:     l.base + int64(l.pos),
+	}
+}
+
+// offset returns the offset within the data of the next byte to be read,
+// which following a call to next (without a token having been peeked) is the
+// end of the token returned.
+func (l *lexer) offset() int64 {
+	return l.base + int64(l.pos)
+}
+
+// fill reads more data into the buffer, discarding any data before mark. It
+// returns the number of bytes discarded (which any positions held within
+// buf must be adjusted by), and whether any data was read.
+func (l *lexer) fill() (int, bool) {
+
+	if l.eof || l.err != nil {
+		return 0, false
+	}
+
+	shift := l.mark
+
+	if shift > 0 {
+		n := copy(l.buf, l.buf[shift:])
+		l.buf = l.buf[:n]
+
+		l.base += int64(shift)
+		l.pos -= shift
+		l.lineStart -= shift
+		l.mark = 0
+	}
+
+	// grow buffer where full
+	if len(l.buf) == cap(l.buf) {
+		grown := make([]byte, len(l.buf), 2*cap(l.buf))
+		copy(grown, l.buf)
+		l.buf = grown
+	}
+
+	for {
+		n, err := l.r.Read(l.buf[len(l.buf):cap(l.buf)])
+		l.buf = l.buf[:len(l.buf)+n]
+
+		if err == io.EOF {
+			l.eof = true
+		} else if err != nil {
+			l.err = fmt.Errorf("unable to read line %d: %w", l.lineNum, err)
+		}
+
+		if n > 0 || l.eof || l.err != nil {
+			return shift, n > 0
+		}
+	}
+}
+
+// ensure makes at least n bytes available from pos within the buffer,
+// returning the number of bytes discarded from the buffer in doing so, and
+// whether the bytes are available.
+func (l *lexer) ensure(n int) (int, bool) {
+
+	shifted := 0
+
+	for len(l.buf)-l.pos < n {
+		shift, ok := l.fill()
+		shifted += shift
+
+		if !ok {
+			return shifted, false
+		}
+	}
+
+	return shifted, true
+}
+
+// newLine records that a line break has been read at pos.
+func (l *lexer) newLine() {
+	l.lineNum++
+	l.lineStart = l.pos + 1
+}
+
+// skip advances past any whitespace and comments, returning false if the
+// end of the data is reached.
+func (l *lexer) skip() bool {
+
+	comment := false
+
+	for {
+		if l.pos >= len(l.buf) {
+			// only the current line needs to be retained
+			l.mark = l.lineStart
+
+			if _, ok := l.fill(); !ok {
+				return false
+			}
+		}
+
+		c := l.buf[l.pos]
+
+		switch {
+		case c == '\n':
+			l.newLine()
+			l.pos++
+			comment = false
+
+		// comments run until the end of the line
+		case comment:
+			if i := bytes.IndexByte(l.buf[l.pos:], '\n'); i >= 0 {
+				l.pos += i
+			} else {
+				l.pos = len(l.buf)
+			}
+
+		case isSpace(c):
+			l.pos++
+
+		case c == '/':
+			l.ensure(2)
+
+			if l.pos+1 < len(l.buf) && l.buf[l.pos+1] == '/' {
+				comment = true
+				continue
+			}
+
+			return true
+
+		default:
+			return true
+		}
+	}
+}
+
+// readQuoted reads a quoted string (beginning at the position of start),
+// which can span multiple lines.
+func (l *lexer) readQuoted(start token) (token, error) {
+
+	// skip opening quote
+	l.pos++
+
+	// begin is the position of the data not yet added to the value, with
+	// the value held in scratch once any escape sequence has been decoded
+	begin := l.pos
+	decoded := false
+	l.scratch = l.scratch[:0]
+
+	for {
+		if l.pos >= len(l.buf) {
+			shift, ok := l.fill()
+			begin -= shift
+
+			if !ok {
+				start.value = l.value(l.buf[begin:l.pos], decoded)
+				return start, newParseError(start, "unterminated string", "'\"'")
+			}
+		}
+
+		c := l.buf[l.pos]
+
+		switch {
+
+		// closing quote
+		case c == '"':
+			start.value = l.value(l.buf[begin:l.pos], decoded)
+			start.quoted = true
+			l.pos++
+			return start, nil
+
+		// strings that span multiple lines retain the line break, though not
+		// any carriage return preceding it
+		case c == '\n':
+			if l.pos > begin && l.buf[l.pos-1] == '\r' {
+				l.scratch = append(l.scratch, l.buf[begin:l.pos-1]...)
+				begin = l.pos
+				decoded = true
+			}
+
+			l.newLine()
+			l.pos++
+
+		// escape sequence
+		case c == '\\' && l.escapes:
+			shift, _ := l.ensure(2)
+			begin -= shift
+
+			if l.pos+1 >= len(l.buf) {
+				l.pos++
+				continue
+			}
+
+			decodedChar, ok := unescape(l.buf[l.pos+1])
+			if !ok {
+				l.pos++
+				continue
+			}
+
+			l.scratch = append(l.scratch, l.buf[begin:l.pos]...)
+			l.scratch = append(l.scratch, decodedChar)
+			l.pos += 2
+			begin = l.pos
+			decoded = true
+
+		default:
+			l.pos++
+		}
+	}
+}
+
+// readConditional reads a conditional (beginning at the position of start),
+// which must be closed on the same line.
+func (l *lexer) readConditional(start token) (token, error) {
+
+	// skip opening bracket
+	l.pos++
+	begin := l.pos
+
+	for {
+		if l.pos >= len(l.buf) {
+			shift, ok := l.fill()
+			begin -= shift
+
+			if !ok {
+				break
+			}
+		}
+
+		c := l.buf[l.pos]
+
+		if c == ']' {
+			start.value = strings.TrimSpace(string(l.buf[begin:l.pos]))
+			l.pos++
+			return start, nil
+		}
+
+		if c == '\n' {
+			break
+		}
+
+		l.pos++
+	}
+
+	start.value = strings.TrimSpace(string(l.buf[begin:l.pos]))
+
+	return start, newParseError(start, "unterminated conditional", "']'")
+}
+
+// readUnquoted reads an unquoted string (beginning at the position of
+// start), which runs until whitespace, a quote, a brace or a comment.
+func (l *lexer) readUnquoted(start token) token {
+
+	begin := l.pos
+
+	for {
+		if l.pos >= len(l.buf) {
+			shift, ok := l.fill()
+			begin -= shift
+
+			if !ok {
+				break
+			}
+		}
+
+		c := l.buf[l.pos]
+		if isSpace(c) || c == '\n' || c == '"' || c == '{' || c == '}' {
+			break
+		}
+
+		// comments end the string
+		if c == '/' {
+			shift, _ := l.ensure(2)
+			begin -= shift
+
+			if l.pos+1 < len(l.buf) && l.buf[l.pos+1] == '/' {
+				break
+			}
+		}
+
+		l.pos++
+	}
+
+	start.value = l.value(l.buf[begin:l.pos], false)
+
+	return start
+}
+
+// value returns the string value of a token made up of the provided data,
+// appended to scratch where decoded is true.
+func (l *lexer) value(data []byte, decoded bool) string {
+
+	if decoded {
+		l.scratch = append(l.scratch, data...)
+		data = l.scratch
+	}
+
+	if len(data) > maxInternLength {
+		return string(data)
+	}
+
+	// lookups using a converted []byte don't allocate
+	if s, ok := l.interned[string(data)]; ok {
+		return s
+	}
+
+	s := string(data)
+
+	if len(l.interned) < maxInterned {
+		l.interned[s] = s
+	}
+
+	return s
+}
+
+// snippet returns the line beginning at the provided offset within the data,
+// or an empty string if the line is no longer buffered.
+func (l *lexer) snippet(lineOffset int64) string {
+
+	start := lineOffset - l.base
+	if start < 0 || start > int64(len(l.buf)) {
+		return ""
+	}
+
+	line := l.buf[start:]
+
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+
+	return string(bytes.TrimSuffix(line, []byte("\r")))
+}
+
+// isSpace returns whether c is a whitespace character (other than a line
+// break).
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// unescape returns the character represented by the escape sequence of a
+// backslash followed by c, and false if the sequence isn't recognised (in
+// which case the backslash is retained as is).
+func unescape(c byte) (byte, bool) {
+
+	switch c {
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'r':
+		return '\r', true
+	case 'v':
+		return '\v', true
+	case 'b':
+		return '\b', true
+	case 'f':
+		return '\f', true
+	case 'a':
+		return '\a', true
+	case '\\', '"', '\'', '?':
+		return c, true
+	}
+
+	return 0, false
+}
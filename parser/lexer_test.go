@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLexerUnquoted(t *testing.T) {
+
+	for _, test := range []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{
+			name:     "whitespace",
+			source:   "key value",
+			expected: []string{"key", "value"},
+		},
+		{
+			name:     "comment after key",
+			source:   "key//comment\nvalue",
+			expected: []string{"key", "value"},
+		},
+		{
+			name:     "comment after value",
+			source:   "key value// comment",
+			expected: []string{"key", "value"},
+		},
+		{
+			name:     "single slash",
+			source:   "key a/b",
+			expected: []string{"key", "a/b"},
+		},
+		{
+			name:     "braces",
+			source:   "section{key value}",
+			expected: []string{"section", "{", "key", "value", "}"},
+		},
+		{
+			name:     "quoted",
+			source:   "\"key\"//comment\n\"value//not a comment\"",
+			expected: []string{"key", "value//not a comment"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			// data is read a byte at a time, so that tokens span reads
+			l := newLexer(iotest.OneByteReader(strings.NewReader(test.source)), true)
+
+			var actual []string
+
+			for {
+				tok, err := l.next()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if tok.typ == tokenEOF {
+					break
+				}
+
+				switch tok.typ {
+				case tokenOpen:
+					actual = append(actual, "{")
+
+				case tokenClose:
+					actual = append(actual, "}")
+
+				default:
+					actual = append(actual, tok.value)
+				}
+			}
+
+			if strings.Join(actual, "|") != strings.Join(test.expected, "|") {
+				t.Errorf("tokens = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
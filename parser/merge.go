@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	// DeleteMarker is the value that, within an overlay passed to Merge,
+	// removes the key (whether a value or a section) from the result, e.g.
+	//
+	//	"paint_kits"
+	//	{
+	//		"1234"		"__delete__"
+	//	}
+	DeleteMarker = "__delete__"
+)
+
+// ChangeType identifies how a Change altered a key.
+type ChangeType int
+
+const (
+	// ChangeAdded is a key that didn't previously exist.
+	ChangeAdded ChangeType = iota
+
+	// ChangeModified is an existing key whose value was replaced.
+	ChangeModified
+
+	// ChangeDeleted is an existing key removed by a DeleteMarker.
+	ChangeDeleted
+)
+
+// String returns the name of the ChangeType.
+func (c ChangeType) String() string {
+
+	switch c {
+	case ChangeAdded:
+		return "added"
+
+	case ChangeModified:
+		return "modified"
+
+	case ChangeDeleted:
+		return "deleted"
+	}
+
+	return fmt.Sprintf("ChangeType(%d)", int(c))
+}
+
+// Change is a single key changed by an overlay.
+type Change struct {
+	Type ChangeType
+
+	// Path is the "/" separated path of the key from the root.
+	Path string
+
+	// Old is the value before the change (nil for ChangeAdded), and New the
+	// value after it (nil for ChangeDeleted).
+	Old interface{}
+	New interface{}
+}
+
+// String returns a description of the Change.
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s", c.Type, c.Path)
+}
+
+// MergeReport holds the changes made by each overlay passed to Merge, with
+// the changes of the first overlay at index 0.
+type MergeReport [][]Change
+
+// Merge returns the result of layering each of the overlays, in order, on
+// top of base (all as returned by Parse), along with a report of the keys
+// each overlay changed. Neither base nor the overlays are modified.
+//
+// Sections are merged recursively, with the entries of an overlay added to
+// those of the same section in the base, while values (and sections
+// replacing values, or vice versa) replace whatever they overlay. A key with
+// the value DeleteMarker is removed. As within the engine, keys are matched
+// regardless of case (preferring an exact match), with the casing of the
+// base retained.
+//
+// Keys are merged in sorted order, so both the result and the report are
+// deterministic.
+func Merge(base map[string]interface{}, overlays ...map[string]interface{}) (map[string]interface{}, MergeReport) {
+
+	response := copyValue(base).(map[string]interface{})
+	report := make(MergeReport, len(overlays))
+
+	for i, overlay := range overlays {
+		report[i] = mergeMap(response, overlay, "", nil)
+	}
+
+	return response, report
+}
+
+// mergeMap merges overlay into dst, appending the changes made to changes.
+func mergeMap(dst, overlay map[string]interface{}, path string, changes []Change) []Change {
+
+	keys := make([]string, 0, len(overlay))
+	for key := range overlay {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := overlay[key]
+
+		// the overlay key is matched against an existing key regardless of
+		// case, as LookupFold
+		dstKey, exists := matchFold(dst, key)
+		if !exists {
+			dstKey = key
+		}
+
+		keyPath := joinPath(path, dstKey)
+
+		if value == DeleteMarker {
+			if exists {
+				changes = append(changes, Change{Type: ChangeDeleted, Path: keyPath, Old: dst[dstKey]})
+				delete(dst, dstKey)
+			}
+
+			continue
+		}
+
+		existing, existingSection := dst[dstKey].(map[string]interface{})
+		section, isSection := value.(map[string]interface{})
+
+		switch {
+
+		// sections are merged recursively
+		case exists && existingSection && isSection:
+			changes = mergeMap(existing, section, keyPath, changes)
+
+		// the report holds its own copy of new values, which later overlays
+		// can change within the result
+		case !exists:
+			dst[dstKey] = copyValue(value)
+			changes = append(changes, Change{Type: ChangeAdded, Path: keyPath, New: copyValue(value)})
+
+		case !reflect.DeepEqual(dst[dstKey], value):
+			old := dst[dstKey]
+			dst[dstKey] = copyValue(value)
+			changes = append(changes, Change{Type: ChangeModified, Path: keyPath, Old: old, New: copyValue(value)})
+		}
+	}
+
+	return changes
+}
+
+// copyValue returns a deep copy of the provided value (as held within the
+// maps returned by Parse), with DeleteMarkers removed from any section.
+func copyValue(value interface{}) interface{} {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		response := make(map[string]interface{}, len(v))
+
+		for key, child := range v {
+			if child == DeleteMarker {
+				continue
+			}
+
+			response[key] = copyValue(child)
+		}
+
+		return response
+
+	case []interface{}:
+		response := make([]interface{}, len(v))
+
+		for i, child := range v {
+			response[i] = copyValue(child)
+		}
+
+		return response
+	}
+
+	return value
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// NodeType represents the kind of data held by a Node.
+type NodeType int
+
+const (
+	// ValueNode is a key value pair, with the data held in Node.Value
+	ValueNode NodeType = iota
+
+	// SectionNode is a named section, with the data held in Node.Children
+	SectionNode
+
+	// The following are typed key value pairs, which are only produced from
+	// binary KeyValues (see ParseBinary). The data is held in Node.Value in
+	// its text form, and can be retrieved as its original type through
+	// Node.Int, Node.Uint or Node.Float.
+
+	// Int32Node is a key value pair holding a 32-bit signed integer
+	Int32Node
+
+	// Float32Node is a key value pair holding a 32-bit float
+	Float32Node
+
+	// PointerNode is a key value pair holding a 32-bit pointer
+	PointerNode
+
+	// WStringNode is a key value pair holding a wide (UTF-16) string
+	WStringNode
+
+	// ColorNode is a key value pair holding an RGBA color, in the text form
+	// "r g b a"
+	ColorNode
+
+	// Uint64Node is a key value pair holding a 64-bit unsigned integer
+	Uint64Node
+
+	// Int64Node is a key value pair holding a 64-bit signed integer
+	Int64Node
+)
+
+// Node represents a single entry of a parsed VDF document. Unlike the map
+// produced by Parse, a tree of Nodes retains the order the entries were
+// written in, every duplicate key, and the position of each entry within
+// the source.
+type Node struct {
+	Type     NodeType
+	Key      string
+	Value    string
+	Children []*Node
+
+	// Condition is the conditional expression (without its surrounding
+	// brackets) that the entry was written with, e.g. "$WIN32" for
+	// "key" "value" [$WIN32], or empty if the entry is unconditional.
+	Condition string
+
+	// Line and Column are the 1-based position of the Node's key within
+	// the source (0 where the Node wasn't parsed from a source).
+	Line   int
+	Column int
+}
+
+// NewSection returns a new SectionNode with the provided key and children.
+func NewSection(key string, children ...*Node) *Node {
+	return &Node{
+		Type:     SectionNode,
+		Key:      key,
+		Children: children,
+	}
+}
+
+// NewValue returns a new ValueNode with the provided key and value.
+func NewValue(key, value string) *Node {
+	return &Node{
+		Type:  ValueNode,
+		Key:   key,
+		Value: value,
+	}
+}
+
+// IsSection returns whether the Node is a section (as opposed to a key value
+// pair).
+func (n *Node) IsSection() bool {
+	return n.Type == SectionNode
+}
+
+// Int returns the value of the Node as a signed integer.
+func (n *Node) Int() (int64, error) {
+
+	if n.IsSection() {
+		return 0, fmt.Errorf("unable to read section %s as an integer", n.Key)
+	}
+
+	return strconv.ParseInt(n.Value, 10, 64)
+}
+
+// Uint returns the value of the Node as an unsigned integer.
+func (n *Node) Uint() (uint64, error) {
+
+	if n.IsSection() {
+		return 0, fmt.Errorf("unable to read section %s as an unsigned integer", n.Key)
+	}
+
+	return strconv.ParseUint(n.Value, 10, 64)
+}
+
+// Float returns the value of the Node as a float.
+func (n *Node) Float() (float64, error) {
+
+	if n.IsSection() {
+		return 0, fmt.Errorf("unable to read section %s as a float", n.Key)
+	}
+
+	return strconv.ParseFloat(n.Value, 64)
+}
+
+// Find returns the first child of the Node with the provided key, or nil if
+// no such child exists.
+func (n *Node) Find(key string) *Node {
+
+	for _, child := range n.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// FindAll returns every child of the Node with the provided key, in the order
+// they appear.
+func (n *Node) FindAll(key string) []*Node {
+
+	var response []*Node
+
+	for _, child := range n.Children {
+		if child.Key == key {
+			response = append(response, child)
+		}
+	}
+
+	return response
+}
+
+// Map converts the children of the Node into the map[string]interface{}
+// representation returned by Parse.
+//
+// As a map cannot hold duplicate keys, repeated sections are merged together
+// and the last occurrence of a repeated key value pair takes precedence.
+func (n *Node) Map() map[string]interface{} {
+
+	response := make(map[string]interface{}, len(n.Children))
+	mergeIntoMap(response, n.Children)
+
+	return response
+}
+
+// mergeIntoMap adds the provided nodes to the map m, merging any sections
+// with those already present.
+func mergeIntoMap(m map[string]interface{}, nodes []*Node) {
+
+	for _, node := range nodes {
+
+		if !node.IsSection() {
+			m[node.Key] = node.Value
+			continue
+		}
+
+		// if section already exists, add to it rather than replace it
+		section, ok := m[node.Key].(map[string]interface{})
+		if !ok {
+			section = make(map[string]interface{}, len(node.Children))
+			m[node.Key] = section
+		}
+
+		mergeIntoMap(section, node.Children)
+	}
+}
@@ -1,259 +1,260 @@
 package parser
 
 import (
-	"bufio"
-	"errors"
-	"fmt"
+	"bytes"
+	"io"
+	"io/fs"
 	"os"
-	"strings"
+	"path/filepath"
 
 	"github.com/golang-collections/collections/stack"
 )
 
-type token int
-
-const (
-	unknown token = iota
-
-	// root represents the opening position of the file outside of
-	// any sections
-	root
-
-	// section represents a named section of data
-	section
-
-	// opener represents the opening token of a section
-	opener
-
-	// closer represents the closing token of a section
-	closer
-
-	// data represents a line of data
-	data
-
-	// openData represents a line of data that hasn't been concluded
-	// (spills over to another line)
-	openData
-
-	// empty lines (e.g. comments or whitespace) are to be ignored
-	empty
-)
-
-const (
-	whitespaceCutset = " \t"
-)
-
-var (
-	language = map[token]map[token]interface{}{
-		root: {
-			section: struct{}{},
-		},
-		section: {
-			opener: struct{}{},
-		},
-		opener: {
-			section:  struct{}{},
-			data:     struct{}{},
-			openData: struct{}{},
-			closer:   struct{}{},
-		},
-		closer: {
-			section:  struct{}{},
-			data:     struct{}{},
-			openData: struct{}{},
-			closer:   struct{}{},
-		},
-		data: {
-			section:  struct{}{},
-			data:     struct{}{},
-			openData: struct{}{},
-			closer:   struct{}{},
-		},
-		openData: {
-			data:     struct{}{},
-			openData: struct{}{},
-		},
-	}
-)
-
+// Parse opens the file at the provided location and parses its contents
+// into a map of type map[string]interface{}. The file is closed before
+// returning.
+//
+// Any #base or #include directives are resolved relative to the file.
 func Parse(fileLocation string) (map[string]interface{}, error) {
 
-	// initialise/reset
-	dataTree := make(map[string]interface{})
-	openSections := stack.New()
-	openSections.Push(dataTree)
-
-	lastToken := root
-
 	fi, err := os.Open(fileLocation)
 	if err != nil {
 		return nil, err
 	}
 
-	s := bufio.NewScanner(fi)
-
-	lineCount := 0
-	currentLine := ""
-
-	// process lines
-	for s.Scan() {
+	defer fi.Close()
 
-		lineCount++
+	tree, err := ParseTreeWithOptions(fi, ParseOptions{
+		Name:     filepath.Clean(fileLocation),
+		Resolver: DirResolver{},
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if s.Err() != nil {
-			return nil, fmt.Errorf("failed to scan line %d with error: %s", lineCount, s.Err())
-		}
+	return tree.Map(), nil
+}
 
-		if lastToken == openData {
-			currentLine += s.Text()
-		} else {
-			currentLine = s.Text()
-		}
+// ParseBytes parses the provided VDF data into a map of type
+// map[string]interface{}.
+func ParseBytes(data []byte) (map[string]interface{}, error) {
+	return ParseReader(bytes.NewReader(data))
+}
 
-		t, lineData, err := getLineType(currentLine)
-		if err != nil {
-			fmt.Println(s.Text())
-			return nil, fmt.Errorf("unable to parse line %d with error: %s", lineCount, err.Error())
-		}
+// ParseFS opens the named file from the provided file system and parses its
+// contents into a map of type map[string]interface{}. The file is closed
+// before returning.
+//
+// Any #base or #include directives are resolved relative to the file within
+// the same file system.
+func ParseFS(fsys fs.FS, name string) (map[string]interface{}, error) {
 
-		// ignore empty lines
-		if t == empty {
-			currentLine = ""
-			continue
-		}
+	fi, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
 
-		// if token is unexpected
-		if _, ok := language[lastToken][t]; !ok {
-			return nil, fmt.Errorf("unexpected token on line %d", lineCount)
-		}
+	defer fi.Close()
 
-		lastToken = t
+	tree, err := ParseTreeWithOptions(fi, ParseOptions{
+		Name:     name,
+		Resolver: FSResolver{FS: fsys},
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// process token
-		switch t {
+	return tree.Map(), nil
+}
 
-		// section: add new section to current section and add to stack
-		case section:
-			currentSection := openSections.Peek().(map[string]interface{})
-			subsection := lineData[0]
+// ParseReader parses the VDF data read from r into a map of type
+// map[string]interface{}. It is the responsibility of the caller to close r
+// where required.
+func ParseReader(r io.Reader) (map[string]interface{}, error) {
 
-			// if subsection already exists in currentSection
-			if m, ok := currentSection[subsection]; ok {
-				openSections.Push(m)
-				continue
-			}
+	tree, err := ParseTree(r)
+	if err != nil {
+		return nil, err
+	}
 
-			// else add it and push onto stack
-			currentSection[subsection] = make(map[string]interface{})
-			openSections.Push(currentSection[subsection])
-			continue
+	return tree.Map(), nil
+}
 
-		// closer: close currently open section
-		case closer:
-			if openSections.Len() == 0 {
-				return nil, errors.New("unexpected token")
-			}
+// ParseOptions configures how VDF data is parsed.
+type ParseOptions struct {
+
+	// Conditions is the set of symbols defined when evaluating conditionals
+	// (e.g. [$WIN32]). Entries whose conditional doesn't hold are omitted from
+	// the result. Where nil, conditionals aren't evaluated and every entry is
+	// retained, with its conditional available through Node.Condition.
+	Conditions Conditions
+
+	// Resolver opens the files referenced by #base and #include directives.
+	// Where nil, any directive results in an error.
+	Resolver Resolver
+
+	// DisableEscapes disables the decoding of escape sequences (e.g. \n or
+	// \") within quoted strings, with backslashes instead treated as any
+	// other character (and quote marks always end a string), as Valve's own
+	// loader does for some files.
+	DisableEscapes bool
+
+	// Encoding is the character encoding of the data, which is detected
+	// automatically by default (see EncodingAuto).
+	Encoding Encoding
+
+	// Lenient enables error recovery. Rather than stopping at the first
+	// syntax error, each is recorded and parsing continues, with the parsed
+	// tree returned alongside an ErrorList of every error encountered.
+	Lenient bool
+
+	// Name is the name of the document being parsed, which is passed to the
+	// Resolver so that directives can be resolved relative to it.
+	Name string
+
+	// Duplicates is the policy for keys repeated within the same section when
+	// the tree is converted into a map (see ParseReaderWithOptions and
+	// Node.MapWithOptions). The tree itself always retains every entry.
+	Duplicates DuplicatePolicy
+
+	// SectionDuplicates overrides Duplicates for the entries of specific
+	// sections, keyed by the "/" separated path of the section from the root
+	// (e.g. "items_game/client_loot_lists"). The policy also applies to the
+	// section's descendants, unless itself overridden.
+	SectionDuplicates map[string]DuplicatePolicy
+
+	// FoldKeys treats keys that differ only in case as the same key when the
+	// tree is converted into a map, as the engine does, with repeated keys
+	// handled by the Duplicates policy. The casing of the first occurrence of
+	// a key is retained in the map (see LookupFold for case-insensitive
+	// lookups within it).
+	FoldKeys bool
+}
 
-			openSections.Pop()
-			continue
+// ParseTree parses the VDF data read from r into a tree of Nodes, returning
+// the root section of the document (whose children are the top level
+// entries). Unlike ParseReader, the returned tree retains key order,
+// duplicate keys and the position of each entry within the source.
+func ParseTree(r io.Reader) (*Node, error) {
+	return ParseTreeWithOptions(r, ParseOptions{})
+}
 
-		// data: add data to currently open section
-		case data:
-			currentSection := openSections.Peek().(map[string]interface{})
-			currentSection[lineData[0]] = lineData[1]
-			continue
+// ParseTreeWithOptions parses the VDF data read from r into a tree of Nodes
+// in the same way as ParseTree, applying the provided options.
+//
+// Syntax errors are returned as a *ParseError, or in lenient mode, as an
+// ErrorList returned alongside the (partially) parsed tree.
+func ParseTreeWithOptions(r io.Reader, opts ParseOptions) (*Node, error) {
 
-		// opener, empty: ignore line as doesn't contain data
-		case opener, openData, empty:
-			continue
-		}
+	var chain []string
+	if opts.Name != "" {
+		chain = append(chain, opts.Name)
 	}
 
-	return dataTree, nil
+	return parseTree(r, opts, chain)
 }
 
-func getLineType(line string) (token, []string, error) {
-
-	line = strings.Trim(line, whitespaceCutset)
+// parseTree implements ParseTreeWithOptions, with chain holding the names of
+// the documents currently being parsed through #base and #include
+// directives.
+func parseTree(r io.Reader, opts ParseOptions, chain []string) (*Node, error) {
 
-	// if line is 0 chars after trim (or is a comment), it is a blank line to ignore
-	if len(line) == 0 || strings.HasPrefix(line, "//") {
-		return empty, nil, nil
-	}
+	d := NewDecoderWithOptions(r, opts)
 
-	// opener/closer is a single { or } (respectively)
-	if len(line) == 1 {
-
-		if line == "{" {
-			return opener, nil, nil
-		}
+	b := &treeBuilder{}
 
-		if line == "}" {
-			return closer, nil, nil
-		}
+	tree, err := b.build(d)
+	if err != nil {
+		return nil, err
 	}
 
-	split, open := parseDataLine(line)
-	if open {
-		return openData, nil, nil
+	diagnostics, err := resolveDirectives(tree, b.directives, opts, chain)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(split) == 1 {
-		return section, split, nil
-	}
+	errs := append(d.Errors(), diagnostics...)
 
-	if len(split) == 2 {
-		return data, split, nil
+	if len(errs) > 0 {
+		return tree, errs
 	}
 
-	return unknown, nil, errors.New("unrecognised line type")
+	return tree, nil
 }
 
-// parseLineData returns the string sub-elements of a data line as a slice
-// of strings, and a boolean to highlight whether the line is open ended.
-func parseDataLine(line string) ([]string, bool) {
+// nodeBatchSize is the number of Nodes allocated at a time by a treeBuilder.
+const nodeBatchSize = 256
+
+// treeBuilder builds a tree of Nodes from the events of a Decoder.
+type treeBuilder struct {
 
-	subStrings := make([]string, 0)
-	currentString := ""
+	// directives, which are resolved once the document has been parsed
+	directives []directiveRef
+
+	// nodes holds Nodes allocated in bulk but not yet used, reducing the
+	// number of allocations for large documents
+	nodes []Node
+}
 
-	quoted := false
+// build reads every event of the Decoder, returning the root section.
+func (b *treeBuilder) build(d *Decoder) (*Node, error) {
+
+	// initialise/reset
+	dataTree := NewSection("")
+	openSections := stack.New()
+	openSections.Push(dataTree)
 
-	for i, c := range line {
+	for {
+		e, err := d.event()
+		if err == io.EOF {
+			return dataTree, nil
+		}
 
-		// break if string is comment (comments begin with '//')
-		if !quoted && c == '/' {
-			if i < len(line)-1 {
-				if line[i+1] == '/' {
-					break
-				}
-			}
+		if err != nil {
+			return nil, err
 		}
 
-		// if we reach a quote mark
-		if c == '"' {
+		currentSection := openSections.Peek().(*Node)
 
-			// and the quote isn't escaped
-			if i == 0 || line[i-1] != '\\' {
+		switch e.typ {
+		case eventStartSection:
+			node := b.newNode(SectionNode, e)
+			currentSection.Children = append(currentSection.Children, node)
+			openSections.Push(node)
 
-				// add current string if necessary and reset
-				if quoted {
-					subStrings = append(subStrings, currentString)
-					currentString = ""
-				}
+		case eventKeyValue:
+			node := b.newNode(ValueNode, e)
+			node.Value = e.value
+			currentSection.Children = append(currentSection.Children, node)
 
-				// flip quoted
-				quoted = !quoted
-				continue
-			}
-		}
+		case eventEndSection:
+			openSections.Pop()
 
-		// ignore anything outside of quotes
-		if !quoted {
-			continue
+		case eventDirective:
+			b.directives = append(b.directives, directiveRef{
+				kind: e.key,
+				name: e.value,
+				line: e.line,
+			})
 		}
+	}
+}
+
+// newNode returns a new Node of the provided type, taking its key, condition
+// and position from the event.
+func (b *treeBuilder) newNode(typ NodeType, e event) *Node {
 
-		currentString += string(c)
+	if len(b.nodes) == 0 {
+		b.nodes = make([]Node, nodeBatchSize)
 	}
 
-	return subStrings, quoted
+	node := &b.nodes[0]
+	b.nodes = b.nodes[1:]
+
+	node.Type = typ
+	node.Key = e.key
+	node.Condition = e.condition
+	node.Line, node.Column = e.line, e.column
+
+	return node
 }
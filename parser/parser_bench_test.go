@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// benchmarkData returns a synthetic document resembling items_game.txt, made
+// up of the provided number of paint kits and items.
+func benchmarkData(entries int) []byte {
+
+	var buf bytes.Buffer
+
+	buf.WriteString("\"items_game\"\n{\n\t\"paint_kits\"\n\t{\n")
+
+	for i := 0; i < entries; i++ {
+		fmt.Fprintf(&buf, "\t\t\"%d\"\n\t\t{\n", i)
+		fmt.Fprintf(&buf, "\t\t\t\"name\"\t\t\"paintkit_%d\"\n", i)
+		fmt.Fprintf(&buf, "\t\t\t\"description_string\"\t\t\"#PaintKit_%d\"\n", i)
+		fmt.Fprintf(&buf, "\t\t\t\"description_tag\"\t\t\"#PaintKit_%d_Tag\"\n", i)
+		fmt.Fprintf(&buf, "\t\t\t\"style\"\t\t\"%d\"\n", i%9+1)
+		buf.WriteString("\t\t\t\"wear_remap_min\"\t\t\"0.000000\"\n")
+		buf.WriteString("\t\t\t\"wear_remap_max\"\t\t\"0.800000\"\n")
+		buf.WriteString("\t\t}\n")
+	}
+
+	buf.WriteString("\t}\n\t\"items\"\n\t{\n")
+
+	for i := 0; i < entries; i++ {
+		fmt.Fprintf(&buf, "\t\t\"%d\"\n\t\t{\n", i)
+		fmt.Fprintf(&buf, "\t\t\t\"name\"\t\t\"item_%d\"\n", i)
+		buf.WriteString("\t\t\t\"prefab\"\t\t\"weapon_case\"\n")
+		fmt.Fprintf(&buf, "\t\t\t\"item_name\"\t\t\"#CSGO_Item_%d\"\n", i)
+		buf.WriteString("\t\t\t\"item_description\"\t\t\"A description spanning\\nmultiple lines, with \\\"escapes\\\"\"\n")
+		buf.WriteString("\t\t\t\"attributes\"\n\t\t\t{\n")
+		buf.WriteString("\t\t\t\t\"set supply crate series\"\t\t{ \"attribute_class\" \"supply_crate_series\" \"value\" \"1\" }\n")
+		buf.WriteString("\t\t\t}\n")
+		buf.WriteString("\t\t}\n")
+	}
+
+	buf.WriteString("\t}\n}\n")
+
+	return buf.Bytes()
+}
+
+// goldenFiles maps the documents parsed by TestParseMatchesGolden to the
+// output of the line based lexer (i.e. Parse, prior to the byte buffer lexer)
+// for each, as json within testdata. The golden files mustn't be regenerated
+// from the current lexer.
+var goldenFiles = map[string]func() ([]byte, error){
+	"testdata/benchmark.golden.json": func() ([]byte, error) {
+		return benchmarkData(50), nil
+	},
+	"testdata/syntax.golden.json": func() ([]byte, error) {
+		return os.ReadFile("testdata/syntax.vdf")
+	},
+}
+
+// TestParseMatchesGolden verifies that the lexer produces identical trees to
+// the line based lexer it replaced.
+func TestParseMatchesGolden(t *testing.T) {
+
+	for golden, source := range goldenFiles {
+		t.Run(golden, func(t *testing.T) {
+
+			data, err := source()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			goldenData, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var expected map[string]interface{}
+			if err := json.Unmarshal(goldenData, &expected); err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := ParseReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(parsed, expected) {
+				t.Error("ParseReader output differs from golden file")
+			}
+
+			tree, err := ParseTree(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tree.Map(), expected) {
+				t.Error("ParseTree output differs from golden file")
+			}
+		})
+	}
+}
+
+func BenchmarkParseTree(b *testing.B) {
+
+	data := benchmarkData(10000)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseTree(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseReader(b *testing.B) {
+
+	data := benchmarkData(10000)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseReader(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
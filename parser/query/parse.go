@@ -0,0 +1,435 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError is returned by Compile where an expression is invalid.
+type SyntaxError struct {
+
+	// Expr is the expression being compiled.
+	Expr string
+
+	// Column is the (1 based) column of the expression at which the error
+	// occurred.
+	Column int
+
+	// Msg is a description of the error.
+	Msg string
+}
+
+// Error implements error.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid query \"%s\": column %d: %s", e.Expr, e.Column, e.Msg)
+}
+
+// tokenType identifies the type of a token within an expression.
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenName
+	tokenString
+	tokenSlash
+	tokenOpenBracket
+	tokenCloseBracket
+	tokenOpenParen
+	tokenCloseParen
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+// token is a single token of an expression.
+type token struct {
+	typ   tokenType
+	value string
+
+	// pos is the byte offset of the token within the expression
+	pos int
+}
+
+// String returns a description of the token for use within errors.
+func (t token) String() string {
+
+	switch t.typ {
+	case tokenEOF:
+		return "end of query"
+
+	case tokenString:
+		return fmt.Sprintf("string \"%s\"", t.value)
+
+	case tokenName:
+		return fmt.Sprintf("\"%s\"", t.value)
+	}
+
+	return fmt.Sprintf("'%s'", t.value)
+}
+
+var (
+	// singleTokens maps the characters that are tokens by themselves to
+	// their type.
+	singleTokens = map[rune]tokenType{
+		'/': tokenSlash,
+		'[': tokenOpenBracket,
+		']': tokenCloseBracket,
+		'(': tokenOpenParen,
+		')': tokenCloseParen,
+		'<': tokenOperator,
+		'>': tokenOperator,
+		'!': tokenNot,
+	}
+)
+
+// isNameChar returns whether r can appear within an unquoted name.
+func isNameChar(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune("/[]()\"!=<>&|", r)
+}
+
+// tokenize splits the provided expression into tokens.
+func tokenize(expr string) ([]token, error) {
+
+	var tokens []token
+
+	for pos := 0; pos < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[pos:])
+
+		if unicode.IsSpace(r) {
+			pos += size
+			continue
+		}
+
+		// two character tokens
+		if pos+1 < len(expr) {
+			switch pair := expr[pos : pos+2]; pair {
+			case "==", "!=", "<=", ">=":
+				tokens = append(tokens, token{typ: tokenOperator, value: pair, pos: pos})
+				pos += 2
+				continue
+
+			case "&&":
+				tokens = append(tokens, token{typ: tokenAnd, value: pair, pos: pos})
+				pos += 2
+				continue
+
+			case "||":
+				tokens = append(tokens, token{typ: tokenOr, value: pair, pos: pos})
+				pos += 2
+				continue
+			}
+		}
+
+		if typ, ok := singleTokens[r]; ok {
+			tokens = append(tokens, token{typ: typ, value: string(r), pos: pos})
+			pos += size
+			continue
+		}
+
+		switch {
+		case r == '"':
+			value, end, err := readString(expr, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{typ: tokenString, value: value, pos: pos})
+			pos = end
+
+		case isNameChar(r):
+			start := pos
+			for pos < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[pos:])
+				if !isNameChar(r) {
+					break
+				}
+
+				pos += size
+			}
+
+			tokens = append(tokens, token{typ: tokenName, value: expr[start:pos], pos: start})
+
+		default:
+			return nil, &SyntaxError{Expr: expr, Column: pos + 1, Msg: fmt.Sprintf("unexpected character '%c'", r)}
+		}
+	}
+
+	return append(tokens, token{typ: tokenEOF, pos: len(expr)}), nil
+}
+
+// readString reads the quoted string beginning at start, returning its
+// (unescaped) value and the position following its closing quote.
+func readString(expr string, start int) (string, int, error) {
+
+	var value strings.Builder
+
+	for pos := start + 1; pos < len(expr); pos++ {
+		switch c := expr[pos]; c {
+		case '"':
+			return value.String(), pos + 1, nil
+
+		case '\\':
+			if pos+1 < len(expr) {
+				pos++
+			}
+
+			value.WriteByte(expr[pos])
+
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	return "", 0, &SyntaxError{Expr: expr, Column: start + 1, Msg: "unterminated string"}
+}
+
+// exprParser builds a Query from the tokens of an expression.
+//
+// The grammar is as follows, with predicates being evaluated against each
+// value selected by the step they follow:
+//
+//	query      = path [ operator literal ]
+//	path       = [ "/" ] step { "/" step }
+//	step       = ( name | string ) { "[" or "]" }
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | path [ operator literal ]
+type exprParser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+// peek returns the next token without consuming it.
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the next token.
+func (p *exprParser) next() token {
+
+	t := p.tokens[p.pos]
+
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// errorf returns a SyntaxError at the position of t.
+func (p *exprParser) errorf(t token, format string, args ...interface{}) error {
+	return &SyntaxError{Expr: p.expr, Column: t.pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// expect consumes the next token, returning an error if it isn't of the
+// provided type.
+func (p *exprParser) expect(typ tokenType, description string) error {
+
+	if t := p.next(); t.typ != typ {
+		return p.errorf(t, "unexpected %s (expected %s)", t, description)
+	}
+
+	return nil
+}
+
+// parseQuery parses the whole expression.
+func (p *exprParser) parseQuery() ([]step, error) {
+
+	steps, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	// a trailing comparison filters the selected values themselves
+	if p.peek().typ == tokenOperator {
+		cmp, err := p.parseComparison([]step{{kind: stepSelf}})
+		if err != nil {
+			return nil, err
+		}
+
+		last := &steps[len(steps)-1]
+		last.predicates = append(last.predicates, cmp)
+	}
+
+	if t := p.next(); t.typ != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s (expected '/', '[' or operator)", t)
+	}
+
+	return steps, nil
+}
+
+// parsePath parses a path of one or more steps.
+func (p *exprParser) parsePath() ([]step, error) {
+
+	// paths are always relative to the value they are applied to, so a
+	// leading slash is optional
+	if p.peek().typ == tokenSlash {
+		p.next()
+	}
+
+	var steps []step
+
+	for {
+		s, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, s)
+
+		if p.peek().typ != tokenSlash {
+			return steps, nil
+		}
+
+		p.next()
+	}
+}
+
+// parseStep parses a single step of a path, along with its predicates.
+func (p *exprParser) parseStep() (step, error) {
+
+	var s step
+
+	switch t := p.next(); t.typ {
+	case tokenString:
+		s = step{kind: stepKey, key: t.value}
+
+	case tokenName:
+		switch t.value {
+		case "*":
+			s = step{kind: stepWildcard}
+
+		case "**":
+			s = step{kind: stepRecursive}
+
+		case ".":
+			s = step{kind: stepSelf}
+
+		default:
+			s = step{kind: stepKey, key: t.value, glob: strings.Contains(t.value, "*")}
+		}
+
+	default:
+		return step{}, p.errorf(t, "unexpected %s (expected key)", t)
+	}
+
+	for p.peek().typ == tokenOpenBracket {
+		p.next()
+
+		predicate, err := p.parseOr()
+		if err != nil {
+			return step{}, err
+		}
+
+		if err := p.expect(tokenCloseBracket, "']'"); err != nil {
+			return step{}, err
+		}
+
+		s.predicates = append(s.predicates, predicate)
+	}
+
+	return s, nil
+}
+
+// parseOr parses a predicate of one or more conditions joined by "||".
+func (p *exprParser) parseOr() (predicate, error) {
+
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().typ == tokenOr {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orPredicate{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses a predicate of one or more conditions joined by "&&".
+func (p *exprParser) parseAnd() (predicate, error) {
+
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().typ == tokenAnd {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andPredicate{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseUnary parses a negated or parenthesised predicate, or a comparison.
+func (p *exprParser) parseUnary() (predicate, error) {
+
+	switch p.peek().typ {
+	case tokenNot:
+		p.next()
+
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notPredicate{inner: inner}, nil
+
+	case tokenOpenParen:
+		p.next()
+
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenCloseParen, "')'"); err != nil {
+			return nil, err
+		}
+
+		return inner, nil
+	}
+
+	steps, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().typ != tokenOperator {
+		return comparison{steps: steps}, nil
+	}
+
+	return p.parseComparison(steps)
+}
+
+// parseComparison parses the operator and literal of a comparison against
+// the values selected by the provided steps.
+func (p *exprParser) parseComparison(steps []step) (predicate, error) {
+
+	op := p.next()
+
+	literal := p.next()
+	if literal.typ != tokenString && literal.typ != tokenName {
+		return nil, p.errorf(literal, "unexpected %s (expected value) following '%s'", literal, op.value)
+	}
+
+	return comparison{steps: steps, op: op.value, literal: literal.value}, nil
+}
@@ -0,0 +1,435 @@
+// Package query implements a small path query language over the maps
+// returned by parser.Parse, e.g.
+//
+//	items_game/items/*[prefab == "weapon_case"]/name
+//	items_game/paint_kits/*[wear_remap_max > 0.8]/name
+//	**/sticker_kits/*[item_rarity == "legendary" || item_rarity == "ancient"]
+package query
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query is a compiled query expression, which can be run against any number
+// of documents.
+type Query struct {
+	expr  string
+	steps []step
+}
+
+// Result is a single value selected by a Query.
+type Result struct {
+
+	// Path is the keys of the value from the root of the document.
+	Path []string
+
+	// Value is the selected value, which is a string for a key value pair
+	// or a map[string]interface{} for a section.
+	Value interface{}
+}
+
+// Compile parses the provided expression, returning a *SyntaxError where it
+// is invalid.
+//
+// An expression is a path of steps separated by "/", each of which selects
+// values from those selected by the previous step (beginning with the
+// document itself):
+//
+//   - key: selects the value with the provided key, which can contain "*"
+//     to match any characters (e.g. "set_*"), and must be quoted where it
+//     contains spaces or other special characters (e.g. "set supply crate
+//     series")
+//   - *: selects every value of a section
+//   - **: selects the value itself along with every value nested within it
+//     at any depth (recursive descent)
+//   - .: selects the value itself
+//
+// As within the engine, keys are matched regardless of case. Each step can
+// be followed by one or more predicates within brackets, which filter the
+// selected values. A predicate is a path (relative to the value) optionally
+// compared against a literal using ==, !=, <, <=, > or >=, where the
+// comparison holds if it holds for any value the path selects, and a path
+// alone holds where it selects any value. Predicates can be combined with
+// &&, || and !, and grouped with parentheses. Values are compared as numbers
+// where both are numeric, and as strings otherwise.
+//
+// The expression can also end with a comparison, which filters the values
+// selected by the path itself (e.g. items/*/prefab == "weapon_case").
+func Compile(expr string) (*Query, error) {
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{
+		expr:   expr,
+		tokens: tokens,
+	}
+
+	steps, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Query{
+		expr:  expr,
+		steps: steps,
+	}, nil
+}
+
+// MustCompile is like Compile, but panics where the expression is invalid.
+func MustCompile(expr string) *Query {
+
+	q, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return q
+}
+
+// Run compiles the provided expression and runs it against data.
+func Run(expr string, data map[string]interface{}) ([]Result, error) {
+
+	q, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Run(data), nil
+}
+
+// String returns the expression the Query was compiled from.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Run returns the values of data selected by the Query. Values are returned
+// in document order, with the keys of each section sorted (numerically where
+// both keys are integers, such as item indexes).
+//
+// Where repeated keys have been collected into a []interface{} (see
+// parser.DuplicatesList), each element is treated as a separate value with
+// the same key.
+func (q *Query) Run(data map[string]interface{}) []Result {
+
+	matches := selectPath(q.steps, []Result{{Value: data}})
+
+	if matches == nil {
+		return []Result{}
+	}
+
+	return matches
+}
+
+// FormatPath returns the provided path as a query expression, with keys
+// quoted where required.
+func FormatPath(path []string) string {
+
+	keys := make([]string, len(path))
+
+	for i, key := range path {
+		keys[i] = formatKey(key)
+	}
+
+	return strings.Join(keys, "/")
+}
+
+// formatKey returns the provided key as a step of an expression.
+func formatKey(key string) string {
+
+	quote := key == "" || key == "." || strings.Contains(key, "*")
+
+	for _, r := range key {
+		if !isNameChar(r) {
+			quote = true
+		}
+	}
+
+	if !quote {
+		return key
+	}
+
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(key) + "\""
+}
+
+// stepKind identifies the type of a step.
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepWildcard
+	stepRecursive
+	stepSelf
+)
+
+// step is a single step of a path.
+type step struct {
+	kind stepKind
+
+	// key is the key (or glob pattern, where glob is set) matched by a
+	// stepKey
+	key  string
+	glob bool
+
+	predicates []predicate
+}
+
+// matches returns whether the step matches the provided key.
+func (s step) matches(key string) bool {
+
+	switch s.kind {
+	case stepWildcard:
+		return true
+
+	case stepKey:
+		if s.glob {
+			return matchGlob(strings.ToLower(s.key), strings.ToLower(key))
+		}
+
+		return strings.EqualFold(s.key, key)
+	}
+
+	return false
+}
+
+// selectPath applies each of the steps in turn to the provided values.
+func selectPath(steps []step, values []Result) []Result {
+
+	for _, s := range steps {
+		values = selectStep(s, values)
+	}
+
+	return values
+}
+
+// selectStep applies the step to each of the provided values, returning the
+// selected values that satisfy the step's predicates.
+func selectStep(s step, values []Result) []Result {
+
+	var response []Result
+
+	add := func(r Result) {
+		for _, predicate := range s.predicates {
+			if !predicate.holds(r.Value) {
+				return
+			}
+		}
+
+		response = append(response, r)
+	}
+
+	for _, value := range values {
+		switch s.kind {
+		case stepSelf:
+			add(value)
+
+		case stepRecursive:
+			walk(value, add)
+
+		default:
+			for _, child := range children(value) {
+				if s.matches(child.Path[len(child.Path)-1]) {
+					add(child)
+				}
+			}
+		}
+	}
+
+	return response
+}
+
+// walk calls fn for the provided value and every value nested within it.
+func walk(value Result, fn func(Result)) {
+
+	fn(value)
+
+	for _, child := range children(value) {
+		walk(child, fn)
+	}
+}
+
+// children returns the values of the provided section (or nothing where the
+// value isn't a section), ordered by key.
+func children(value Result) []Result {
+
+	section, ok := value.Value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return lessKey(keys[i], keys[j])
+	})
+
+	response := make([]Result, 0, len(keys))
+
+	for _, key := range keys {
+		path := make([]string, len(value.Path)+1)
+		copy(path, value.Path)
+		path[len(value.Path)] = key
+
+		// repeated keys collected into a list
+		if list, ok := section[key].([]interface{}); ok {
+			for _, v := range list {
+				response = append(response, Result{Path: path, Value: v})
+			}
+
+			continue
+		}
+
+		response = append(response, Result{Path: path, Value: section[key]})
+	}
+
+	return response
+}
+
+// lessKey orders keys, comparing them as numbers where both are integers.
+func lessKey(a, b string) bool {
+
+	ai, aErr := strconv.Atoi(a)
+	bi, bErr := strconv.Atoi(b)
+
+	if aErr == nil && bErr == nil {
+		return ai < bi
+	}
+
+	// integers are ordered before other keys
+	if (aErr == nil) != (bErr == nil) {
+		return aErr == nil
+	}
+
+	return a < b
+}
+
+// matchGlob returns whether s matches the pattern, in which "*" matches any
+// sequence of characters.
+func matchGlob(pattern, s string) bool {
+
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+
+	s = s[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+
+		// the final part must match the end of s
+		if i == len(parts)-2 {
+			return strings.HasSuffix(s, part)
+		}
+
+		index := strings.Index(s, part)
+		if index < 0 {
+			return false
+		}
+
+		s = s[index+len(part):]
+	}
+
+	return s == ""
+}
+
+// predicate is a condition that a value selected by a step must satisfy.
+type predicate interface {
+	holds(value interface{}) bool
+}
+
+// orPredicate holds where either of its predicates hold.
+type orPredicate struct {
+	left, right predicate
+}
+
+func (p orPredicate) holds(value interface{}) bool {
+	return p.left.holds(value) || p.right.holds(value)
+}
+
+// andPredicate holds where both of its predicates hold.
+type andPredicate struct {
+	left, right predicate
+}
+
+func (p andPredicate) holds(value interface{}) bool {
+	return p.left.holds(value) && p.right.holds(value)
+}
+
+// notPredicate holds where its predicate doesn't.
+type notPredicate struct {
+	inner predicate
+}
+
+func (p notPredicate) holds(value interface{}) bool {
+	return !p.inner.holds(value)
+}
+
+// comparison holds where any of the values selected by its path (relative
+// to the value being tested) compare with the literal using op, or where
+// there is no op, where the path selects any value.
+type comparison struct {
+	steps   []step
+	op      string
+	literal string
+}
+
+func (c comparison) holds(value interface{}) bool {
+
+	for _, selected := range selectPath(c.steps, []Result{{Value: value}}) {
+		if c.op == "" {
+			return true
+		}
+
+		if s, ok := selected.Value.(string); ok && compare(s, c.op, c.literal) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compare returns whether the comparison "a op b" holds, comparing a and b
+// as numbers where both are numeric.
+func compare(a, op, b string) bool {
+
+	result := strings.Compare(a, b)
+
+	af, aErr := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	bf, bErr := strconv.ParseFloat(strings.TrimSpace(b), 64)
+
+	if aErr == nil && bErr == nil {
+		switch {
+		case af < bf:
+			result = -1
+		case af > bf:
+			result = 1
+		default:
+			result = 0
+		}
+	}
+
+	switch op {
+	case "==":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	}
+
+	return false
+}
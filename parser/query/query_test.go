@@ -0,0 +1,332 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testDocument resembles the items_game.txt file, as returned by
+// parser.Parse.
+var testDocument = map[string]interface{}{
+	"items_game": map[string]interface{}{
+		"items": map[string]interface{}{
+			"10": map[string]interface{}{
+				"name":   "weapon_ak47",
+				"prefab": "weapon_ak47_prefab",
+			},
+			"2": map[string]interface{}{
+				"name":   "crate_community_1",
+				"prefab": "weapon_case",
+				"attributes": map[string]interface{}{
+					"set supply crate series": map[string]interface{}{
+						"value": "2",
+					},
+				},
+			},
+			"1": map[string]interface{}{
+				"name":   "crate_community_2",
+				"Prefab": "weapon_case",
+			},
+		},
+		"paint_kits": map[string]interface{}{
+			"282": map[string]interface{}{
+				"name":           "cu_ak47_cobra",
+				"wear_remap_max": "0.7",
+			},
+			"2": map[string]interface{}{
+				"name":           "so_olive",
+				"wear_remap_max": "1.0",
+			},
+		},
+		"item_sets": map[string]interface{}{
+			"set_dust_2": map[string]interface{}{
+				"name": "#CSGO_set_dust_2",
+			},
+			"set_community_1": map[string]interface{}{
+				"name": "#CSGO_set_community_1",
+			},
+			"character_set": map[string]interface{}{
+				"name": "#CSGO_character_set",
+			},
+		},
+		"sticker_kits": []interface{}{
+			map[string]interface{}{"name": "first"},
+			map[string]interface{}{"name": "second"},
+		},
+	},
+}
+
+func TestRun(t *testing.T) {
+
+	for _, test := range []struct {
+		name     string
+		expr     string
+		expected []string
+	}{
+		{
+			name:     "key",
+			expr:     "items_game/items/10/name",
+			expected: []string{"items_game/items/10/name=weapon_ak47"},
+		},
+		{
+			name:     "key regardless of case",
+			expr:     "Items_Game/ITEMS/10/Name",
+			expected: []string{"items_game/items/10/name=weapon_ak47"},
+		},
+		{
+			name:     "quoted key",
+			expr:     "items_game/items/2/attributes/\"set supply crate series\"/value",
+			expected: []string{"items_game/items/2/attributes/\"set supply crate series\"/value=2"},
+		},
+		{
+			name: "wildcard ordered numerically",
+			expr: "items_game/items/*/name",
+			expected: []string{
+				"items_game/items/1/name=crate_community_2",
+				"items_game/items/2/name=crate_community_1",
+				"items_game/items/10/name=weapon_ak47",
+			},
+		},
+		{
+			name: "glob prefix",
+			expr: "items_game/item_sets/set_*/name",
+			expected: []string{
+				"items_game/item_sets/set_community_1/name=#CSGO_set_community_1",
+				"items_game/item_sets/set_dust_2/name=#CSGO_set_dust_2",
+			},
+		},
+		{
+			name:     "glob suffix",
+			expr:     "items_game/item_sets/*_2/name",
+			expected: []string{"items_game/item_sets/set_dust_2/name=#CSGO_set_dust_2"},
+		},
+		{
+			name: "glob infix",
+			expr: "items_game/item_sets/*set*/name",
+			expected: []string{
+				"items_game/item_sets/character_set/name=#CSGO_character_set",
+				"items_game/item_sets/set_community_1/name=#CSGO_set_community_1",
+				"items_game/item_sets/set_dust_2/name=#CSGO_set_dust_2",
+			},
+		},
+		{
+			name:     "glob regardless of case",
+			expr:     "items_game/item_sets/SET_DUST*/name",
+			expected: []string{"items_game/item_sets/set_dust_2/name=#CSGO_set_dust_2"},
+		},
+		{
+			name:     "glob without match",
+			expr:     "items_game/item_sets/set_*_3/name",
+			expected: []string{},
+		},
+		{
+			name: "recursive descent",
+			expr: "**/value",
+			expected: []string{
+				"items_game/items/2/attributes/\"set supply crate series\"/value=2",
+			},
+		},
+		{
+			name: "recursive descent within section",
+			expr: "items_game/paint_kits/**/name",
+			expected: []string{
+				"items_game/paint_kits/2/name=so_olive",
+				"items_game/paint_kits/282/name=cu_ak47_cobra",
+			},
+		},
+		{
+			name:     "self",
+			expr:     "items_game/items/10/./name",
+			expected: []string{"items_game/items/10/name=weapon_ak47"},
+		},
+		{
+			name: "predicate equality",
+			expr: "items_game/items/*[prefab == \"weapon_case\"]/name",
+			expected: []string{
+				"items_game/items/1/name=crate_community_2",
+				"items_game/items/2/name=crate_community_1",
+			},
+		},
+		{
+			name:     "predicate inequality",
+			expr:     "items_game/items/*[prefab != \"weapon_case\"]/name",
+			expected: []string{"items_game/items/10/name=weapon_ak47"},
+		},
+		{
+			name:     "predicate numeric",
+			expr:     "items_game/paint_kits/*[wear_remap_max > 0.8]/name",
+			expected: []string{"items_game/paint_kits/2/name=so_olive"},
+		},
+		{
+			name:     "predicate numeric less or equal",
+			expr:     "items_game/paint_kits/*[wear_remap_max <= 0.7]/name",
+			expected: []string{"items_game/paint_kits/282/name=cu_ak47_cobra"},
+		},
+		{
+			name:     "predicate existence",
+			expr:     "items_game/items/*[attributes]/name",
+			expected: []string{"items_game/items/2/name=crate_community_1"},
+		},
+		{
+			name: "predicate negation",
+			expr: "items_game/items/*[!attributes]/name",
+			expected: []string{
+				"items_game/items/1/name=crate_community_2",
+				"items_game/items/10/name=weapon_ak47",
+			},
+		},
+		{
+			name: "predicate or",
+			expr: "items_game/items/*[name == \"weapon_ak47\" || attributes]/name",
+			expected: []string{
+				"items_game/items/2/name=crate_community_1",
+				"items_game/items/10/name=weapon_ak47",
+			},
+		},
+		{
+			name:     "predicate and with grouping",
+			expr:     "items_game/items/*[prefab == \"weapon_case\" && !(attributes)]/name",
+			expected: []string{"items_game/items/1/name=crate_community_2"},
+		},
+		{
+			name:     "predicate nested path",
+			expr:     "items_game/items/*[attributes/*/value == 2]/name",
+			expected: []string{"items_game/items/2/name=crate_community_1"},
+		},
+		{
+			name:     "multiple predicates",
+			expr:     "items_game/items/*[prefab][name == \"crate_community_1\"]/name",
+			expected: []string{"items_game/items/2/name=crate_community_1"},
+		},
+		{
+			name: "trailing comparison",
+			expr: "items_game/items/*/prefab == \"weapon_case\"",
+			expected: []string{
+				"items_game/items/1/Prefab=weapon_case",
+				"items_game/items/2/prefab=weapon_case",
+			},
+		},
+		{
+			name: "repeated keys",
+			expr: "items_game/sticker_kits/name",
+			expected: []string{
+				"items_game/sticker_kits/name=first",
+				"items_game/sticker_kits/name=second",
+			},
+		},
+		{
+			name:     "missing key",
+			expr:     "items_game/missing/*",
+			expected: []string{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			results, err := Run(test.expr, testDocument)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := make([]string, 0, len(results))
+
+			for _, result := range results {
+				value, ok := result.Value.(string)
+				if !ok {
+					value = "{}"
+				}
+
+				actual = append(actual, FormatPath(result.Path)+"="+value)
+			}
+
+			if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("Run(%s) = %q, expected %q", test.expr, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+
+	for _, test := range []struct {
+		expr   string
+		column int
+	}{
+		{expr: "items/*[", column: 9},
+		{expr: "items/*[name ==]", column: 16},
+		{expr: "items/*[name == \"a\"", column: 20},
+		{expr: "items//name", column: 7},
+		{expr: "items/\"name", column: 7},
+		{expr: "items/*[name & x]", column: 14},
+	} {
+		t.Run(test.expr, func(t *testing.T) {
+
+			_, err := Compile(test.expr)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Compile error = %v, expected *SyntaxError", err)
+			}
+
+			if syntaxErr.Column != test.column {
+				t.Errorf("Column = %d, expected %d (%v)", syntaxErr.Column, test.column, err)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+
+	for _, test := range []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{pattern: "set_*", s: "set_dust_2", expected: true},
+		{pattern: "set_*", s: "set", expected: false},
+		{pattern: "*_2", s: "set_dust_2", expected: true},
+		{pattern: "*_2", s: "set_dust_3", expected: false},
+		{pattern: "set_*_2", s: "set_dust_2", expected: true},
+		{pattern: "set_*_2", s: "set_2", expected: false},
+		{pattern: "*a*b", s: "xaxb", expected: true},
+		{pattern: "*ab*b", s: "xab", expected: false},
+		{pattern: "a*a", s: "a", expected: false},
+		{pattern: "a*a", s: "aa", expected: true},
+		{pattern: "*", s: "", expected: true},
+		{pattern: "**", s: "anything", expected: true},
+	} {
+		if actual := matchGlob(test.pattern, test.s); actual != test.expected {
+			t.Errorf("matchGlob(%q, %q) = %v, expected %v", test.pattern, test.s, actual, test.expected)
+		}
+	}
+}
+
+func TestFormatPath(t *testing.T) {
+
+	for _, test := range []struct {
+		path     []string
+		expected string
+	}{
+		{path: []string{"items_game", "items", "10"}, expected: "items_game/items/10"},
+		{path: []string{"set supply crate series"}, expected: "\"set supply crate series\""},
+		{path: []string{"a*b"}, expected: "\"a*b\""},
+		{path: []string{"."}, expected: "\".\""},
+		{path: []string{"say \"hi\""}, expected: "\"say \\\"hi\\\"\""},
+	} {
+		actual := FormatPath(test.path)
+		if actual != test.expected {
+			t.Errorf("FormatPath(%q) = %s, expected %s", test.path, actual, test.expected)
+		}
+
+		// formatted paths select the same path
+		q, err := Compile(actual)
+		if err != nil {
+			t.Errorf("Compile(%s): %v", actual, err)
+			continue
+		}
+
+		if len(q.steps) != len(test.path) {
+			t.Errorf("Compile(%s) has %d steps, expected %d", actual, len(q.steps), len(test.path))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rustedturnip/go-csgo-item-parser/parser"
+	"github.com/rustedturnip/go-csgo-item-parser/parser/query"
+)
+
+// runQuery implements the query subcommand, which runs a query expression
+// against a VDF file and writes the selected values to stdout.
+func runQuery(args []string) error {
+
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	format := flags.String("format", "text", "the output format, one of text, json or vdf")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s query [--format text|json|vdf] <file> <expression>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("query requires a file and an expression")
+	}
+
+	q, err := query.Compile(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	data, err := parser.Parse(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	return writeResults(w, q.Run(data), *format)
+}
+
+// writeResults writes the results of a query to w in the provided format.
+func writeResults(w io.Writer, results []query.Result, format string) error {
+
+	switch format {
+	case "json":
+		type jsonResult struct {
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}
+
+		response := make([]jsonResult, len(results))
+		for i, result := range results {
+			response[i] = jsonResult{Path: query.FormatPath(result.Path), Value: result.Value}
+		}
+
+		encoder := json.NewEncoder(w)
+
+		// set pretty-printing
+		encoder.SetIndent("", "    ")
+
+		return encoder.Encode(response)
+
+	// each value is written as a VDF entry (with its path as a comment)
+	case "vdf", "text":
+		for _, result := range results {
+			key := ""
+			if len(result.Path) > 0 {
+				key = result.Path[len(result.Path)-1]
+			}
+
+			// in text format, values are written on the same line as their path
+			if s, ok := result.Value.(string); ok && format == "text" {
+				fmt.Fprintf(w, "%s\t%s\n", query.FormatPath(result.Path), s)
+				continue
+			}
+
+			var out []byte
+			var err error
+
+			if section, ok := result.Value.(map[string]interface{}); ok && key == "" {
+				out, err = parser.Marshal(section)
+			} else {
+				out, err = parser.Marshal(map[string]interface{}{key: result.Value})
+			}
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(w, "// %s\n%s", query.FormatPath(result.Path), out)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unsupported output format \"%s\"", format)
+}
@@ -0,0 +1,216 @@
+package vpk
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Open implements fs.FS, opening the file or directory with the provided
+// name. Reading a file to its end returns ErrChecksum (wrapped) where its
+// data doesn't match its CRC.
+func (a *Archive) Open(name string) (fs.File, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if entry, ok := a.Entry(name); ok {
+		r, err := a.Reader(entry)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+
+		return &file{r: r, info: fileInfo{name: path.Base(name), entry: entry}}, nil
+	}
+
+	dir, ok := a.dir(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries, err := a.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dirFile{info: fileInfo{name: path.Base(dir)}, entries: entries}, nil
+}
+
+// ReadFile implements fs.ReadFileFS, returning the data of the file with
+// the provided name, or ErrChecksum (wrapped) where its data doesn't match
+// its CRC.
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+
+	entry, ok := a.Entry(name)
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+
+	r, err := a.Reader(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+
+	data := make([]byte, 0, entry.Size())
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := r.Read(buf)
+		data = append(data, buf[:n]...)
+
+		if err == io.EOF {
+			return data, nil
+		}
+
+		if err != nil {
+			return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+		}
+	}
+}
+
+// ReadDir implements fs.ReadDirFS, returning the entries of the directory
+// with the provided name, sorted by name.
+func (a *Archive) ReadDir(name string) ([]fs.DirEntry, error) {
+
+	dir, ok := a.dir(name)
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	names := append([]string(nil), a.dirs[dir]...)
+	sort.Strings(names)
+
+	response := make([]fs.DirEntry, len(names))
+
+	for i, child := range names {
+		childPath := path.Join(dir, child)
+
+		info := fileInfo{name: child}
+		if entry, ok := a.entries[childPath]; ok {
+			info.entry = entry
+		}
+
+		response[i] = fs.FileInfoToDirEntry(info)
+	}
+
+	return response, nil
+}
+
+// Stat implements fs.StatFS.
+func (a *Archive) Stat(name string) (fs.FileInfo, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if entry, ok := a.Entry(name); ok {
+		return fileInfo{name: path.Base(name), entry: entry}, nil
+	}
+
+	dir, ok := a.dir(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return fileInfo{name: path.Base(dir)}, nil
+}
+
+// dir returns the path of the directory with the provided name, which is
+// matched regardless of case where there isn't an exact match.
+func (a *Archive) dir(name string) (string, bool) {
+
+	if _, ok := a.dirs[name]; ok {
+		return name, true
+	}
+
+	name = strings.ToLower(name)
+	_, ok := a.dirs[name]
+
+	return name, ok
+}
+
+// fileInfo implements fs.FileInfo for the files and directories of an
+// Archive, with entry being nil for directories.
+type fileInfo struct {
+	name  string
+	entry *Entry
+}
+
+func (f fileInfo) Name() string { return f.name }
+
+func (f fileInfo) Size() int64 {
+
+	if f.entry == nil {
+		return 0
+	}
+
+	return f.entry.Size()
+}
+
+func (f fileInfo) Mode() fs.FileMode {
+
+	if f.entry == nil {
+		return fs.ModeDir | 0555
+	}
+
+	return 0444
+}
+
+func (f fileInfo) ModTime() time.Time { return time.Time{} }
+
+func (f fileInfo) IsDir() bool { return f.entry == nil }
+
+func (f fileInfo) Sys() interface{} { return f.entry }
+
+// file is an opened file of an Archive.
+type file struct {
+	r    io.Reader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file) Read(p []byte) (int, error) { return f.r.Read(p) }
+
+func (f *file) Close() error { return nil }
+
+// dirFile is an opened directory of an Archive.
+type dirFile struct {
+	info    fileInfo
+	entries []fs.DirEntry
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *dirFile) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return entries, nil
+}
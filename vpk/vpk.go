@@ -0,0 +1,448 @@
+// Package vpk reads Valve's VPK (v1 and v2) packages, such as the
+// pak01_dir.vpk of CS2, in which game files (e.g.
+// scripts/items/items_game.txt) are stored across a directory file and its
+// numbered archives (pak01_000.vpk, pak01_001.vpk...).
+package vpk
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	signature = 0x55AA1234
+
+	// dirArchiveIndex is the archive index of entries whose data is stored
+	// within the directory file itself, following the tree
+	dirArchiveIndex = 0x7FFF
+
+	// entryTerminator ends the fixed size data of each entry
+	entryTerminator = 0xFFFF
+
+	headerSizeV1 = 12
+	headerSizeV2 = 28
+)
+
+var (
+	// ErrChecksum is returned (wrapped) when the data of an entry doesn't
+	// match its CRC32.
+	ErrChecksum = errors.New("vpk: checksum mismatch")
+)
+
+// Entry is a single file within a package.
+type Entry struct {
+
+	// Path is the full path of the file within the package, e.g.
+	// "scripts/items/items_game.txt".
+	Path string
+
+	// CRC is the CRC32 (IEEE) checksum of the file's data.
+	CRC uint32
+
+	// ArchiveIndex is the numbered archive holding the file's data, with
+	// 0x7FFF being the directory file itself.
+	ArchiveIndex uint16
+
+	// Offset and Length are the position of the file's data within its
+	// archive.
+	Offset uint32
+	Length uint32
+
+	// Preload is the data of the file stored within the directory tree,
+	// which precedes the data stored within the archive.
+	Preload []byte
+}
+
+// Size returns the size of the file's data.
+func (e *Entry) Size() int64 {
+	return int64(len(e.Preload)) + int64(e.Length)
+}
+
+// Archive is an opened package, made up of a directory file and its
+// numbered archives. Archive implements fs.FS (along with fs.ReadDirFS,
+// fs.ReadFileFS and fs.StatFS), and is safe for concurrent use.
+type Archive struct {
+
+	// Version is the version of the package format (1 or 2).
+	Version uint32
+
+	// prefix is the path of the directory file without its "_dir.vpk"
+	// suffix, from which the names of the numbered archives are formed
+	prefix string
+
+	// dataOffset is the offset within the directory file of the data of
+	// entries stored within it
+	dataOffset int64
+
+	entries map[string]*Entry
+	paths   []string
+
+	// dirs maps the path of each directory to the names of its children
+	dirs map[string][]string
+
+	mu    sync.Mutex
+	files map[uint16]*os.File
+}
+
+// Open opens the package with the provided directory file (e.g.
+// pak01_dir.vpk), reading its directory tree. The numbered archives are
+// opened as their data is required. The Archive must be closed once no
+// longer required.
+func Open(dirPath string) (*Archive, error) {
+
+	fi, err := os.Open(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		prefix:  strings.TrimSuffix(strings.TrimSuffix(dirPath, ".vpk"), "_dir"),
+		entries: make(map[string]*Entry),
+		dirs:    make(map[string][]string),
+		files:   map[uint16]*os.File{dirArchiveIndex: fi},
+	}
+
+	if err := a.readDirectory(bufio.NewReader(fi)); err != nil {
+		fi.Close()
+		return nil, fmt.Errorf("unable to read vpk directory %s: %w", dirPath, err)
+	}
+
+	return a, nil
+}
+
+// Close closes the directory file and any archives opened.
+func (a *Archive) Close() error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var response error
+
+	for index, fi := range a.files {
+		if err := fi.Close(); err != nil && response == nil {
+			response = err
+		}
+
+		delete(a.files, index)
+	}
+
+	return response
+}
+
+// Entries returns every entry of the package, sorted by path.
+func (a *Archive) Entries() []*Entry {
+
+	response := make([]*Entry, len(a.paths))
+
+	for i, path := range a.paths {
+		response[i] = a.entries[path]
+	}
+
+	return response
+}
+
+// Entry returns the entry with the provided path (name), which is matched
+// regardless of case where there isn't an exact match (as paths within
+// packages are lowercase).
+func (a *Archive) Entry(name string) (*Entry, bool) {
+
+	if entry, ok := a.entries[name]; ok {
+		return entry, true
+	}
+
+	entry, ok := a.entries[strings.ToLower(name)]
+
+	return entry, ok
+}
+
+// Reader returns a reader of the data of the entry, which returns
+// ErrChecksum (wrapped) in place of io.EOF where the data doesn't match the
+// entry's CRC.
+func (a *Archive) Reader(entry *Entry) (io.Reader, error) {
+
+	preload := strings.NewReader(string(entry.Preload))
+
+	if entry.Length == 0 {
+		return newChecksumReader(preload, entry), nil
+	}
+
+	fi, offset, err := a.archive(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	data := io.NewSectionReader(fi, offset, int64(entry.Length))
+
+	return newChecksumReader(io.MultiReader(preload, data), entry), nil
+}
+
+// Verify reads the data of every entry, returning an error for the first
+// whose data doesn't match its CRC.
+func (a *Archive) Verify() error {
+
+	for _, entry := range a.Entries() {
+		r, err := a.Reader(entry)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archive returns the file holding the data of the entry (opening it where
+// required), along with the offset of the data within it.
+func (a *Archive) archive(entry *Entry) (*os.File, int64, error) {
+
+	offset := int64(entry.Offset)
+	if entry.ArchiveIndex == dirArchiveIndex {
+		offset += a.dataOffset
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if fi, ok := a.files[entry.ArchiveIndex]; ok {
+		return fi, offset, nil
+	}
+
+	if len(a.files) == 0 {
+		return nil, 0, errors.New("vpk: archive is closed")
+	}
+
+	fi, err := os.Open(fmt.Sprintf("%s_%03d.vpk", a.prefix, entry.ArchiveIndex))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	a.files[entry.ArchiveIndex] = fi
+
+	return fi, offset, nil
+}
+
+// readDirectory reads the header and directory tree of the package.
+func (a *Archive) readDirectory(r *bufio.Reader) error {
+
+	var header struct {
+		Signature uint32
+		Version   uint32
+		TreeSize  uint32
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("unable to read header: %w", err)
+	}
+
+	if header.Signature != signature {
+		return fmt.Errorf("invalid signature 0x%08X", header.Signature)
+	}
+
+	headerSize := int64(headerSizeV1)
+
+	switch header.Version {
+	case 1:
+
+	// the remainder of the v2 header describes the sections following the
+	// file data (checksums and signature), which aren't required
+	case 2:
+		if _, err := r.Discard(headerSizeV2 - headerSizeV1); err != nil {
+			return fmt.Errorf("unable to read header: %w", err)
+		}
+
+		headerSize = headerSizeV2
+
+	default:
+		return fmt.Errorf("unsupported version %d", header.Version)
+	}
+
+	a.Version = header.Version
+	a.dataOffset = headerSize + int64(header.TreeSize)
+
+	tree := bufio.NewReader(io.LimitReader(r, int64(header.TreeSize)))
+
+	// the tree is made up of extensions, each holding directories, each
+	// holding file names, with each list ending with an empty string
+	for {
+		ext, err := readString(tree)
+		if err != nil || ext == "" {
+			return a.index(err)
+		}
+
+		for {
+			dir, err := readString(tree)
+			if err != nil {
+				return a.index(err)
+			}
+
+			if dir == "" {
+				break
+			}
+
+			for {
+				name, err := readString(tree)
+				if err != nil {
+					return a.index(err)
+				}
+
+				if name == "" {
+					break
+				}
+
+				entry, err := readEntry(tree)
+				if err != nil {
+					return a.index(fmt.Errorf("unable to read entry %s/%s.%s: %w", dir, name, ext, err))
+				}
+
+				entry.Path = entryPath(dir, name, ext)
+				a.entries[entry.Path] = entry
+			}
+		}
+	}
+}
+
+// index builds the sorted paths and directories of the entries read, once
+// the directory tree has been read (returning err, if any, as is).
+func (a *Archive) index(err error) error {
+
+	if err != nil {
+		return err
+	}
+
+	for entryPath := range a.entries {
+		a.paths = append(a.paths, entryPath)
+	}
+
+	sort.Strings(a.paths)
+
+	a.dirs["."] = nil
+
+	for _, child := range a.paths {
+
+		// each parent directory is added, up to the first that already
+		// exists
+		for {
+			dir := path.Dir(child)
+
+			_, exists := a.dirs[dir]
+			a.dirs[dir] = append(a.dirs[dir], child[strings.LastIndexByte(child, '/')+1:])
+
+			if exists {
+				break
+			}
+
+			child = dir
+		}
+	}
+
+	return nil
+}
+
+// entryPath returns the full path of a file from the parts stored within
+// the directory tree, where a single space represents no directory (or no
+// extension).
+func entryPath(dir, name, ext string) string {
+
+	response := name
+	if ext != " " {
+		response += "." + ext
+	}
+
+	if dir != " " {
+		response = strings.Trim(dir, "/") + "/" + response
+	}
+
+	return response
+}
+
+// readString reads a null terminated string.
+func readString(r *bufio.Reader) (string, error) {
+
+	s, err := r.ReadString(0)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return "", fmt.Errorf("unable to read directory tree: %w", err)
+	}
+
+	return s[:len(s)-1], nil
+}
+
+// readEntry reads the fixed size data (and preload data) of an entry.
+func readEntry(r io.Reader) (*Entry, error) {
+
+	var data struct {
+		CRC          uint32
+		PreloadBytes uint16
+		ArchiveIndex uint16
+		Offset       uint32
+		Length       uint32
+		Terminator   uint16
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Terminator != entryTerminator {
+		return nil, fmt.Errorf("invalid terminator 0x%04X", data.Terminator)
+	}
+
+	entry := &Entry{
+		CRC:          data.CRC,
+		ArchiveIndex: data.ArchiveIndex,
+		Offset:       data.Offset,
+		Length:       data.Length,
+		Preload:      make([]byte, data.PreloadBytes),
+	}
+
+	if _, err := io.ReadFull(r, entry.Preload); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// checksumReader verifies the CRC of the data read from an entry once all
+// of it has been read.
+type checksumReader struct {
+	r     io.Reader
+	entry *Entry
+	crc   uint32
+}
+
+// newChecksumReader returns a checksumReader reading the data of entry from
+// r.
+func newChecksumReader(r io.Reader, entry *Entry) *checksumReader {
+	return &checksumReader{
+		r:     r,
+		entry: entry,
+	}
+}
+
+// Read implements io.Reader.
+func (c *checksumReader) Read(p []byte) (int, error) {
+
+	n, err := c.r.Read(p)
+	c.crc = crc32.Update(c.crc, crc32.IEEETable, p[:n])
+
+	if err == io.EOF && c.crc != c.entry.CRC {
+		return n, fmt.Errorf("%w: %s has CRC 0x%08X, expected 0x%08X", ErrChecksum, c.entry.Path, c.crc, c.entry.CRC)
+	}
+
+	return n, err
+}
@@ -0,0 +1,271 @@
+package vpk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testFile is a file written to a package by writePackage.
+type testFile struct {
+	path    string
+	data    string
+	archive uint16
+	preload int
+
+	// corrupt writes an incorrect CRC for the file
+	corrupt bool
+}
+
+// testFiles are stored across the directory file and two numbered archives,
+// with and without preload data, and with and without a directory and
+// extension.
+var testFiles = []testFile{
+	{path: "scripts/items/items_game.txt", data: "\"items_game\"\n{\n\t\"name\"\t\"value\"\n}\n", archive: 0, preload: 8},
+	{path: "scripts/items/base.txt", data: "\"base\" { }", archive: dirArchiveIndex},
+	{path: "resource/csgo_english.txt", data: "\"lang\" { \"Tokens\" { } }", archive: 1},
+	{path: "resource/preloaded.txt", data: "entirely preloaded", archive: dirArchiveIndex, preload: 18},
+	{path: "readme", data: "no directory or extension", archive: 0, preload: 4},
+	{path: "empty.txt", data: "", archive: dirArchiveIndex},
+}
+
+// writePackage writes a package of the provided version made up of files to
+// dir, returning the path of its directory file.
+func writePackage(t *testing.T, dir string, version uint32, files []testFile) string {
+
+	t.Helper()
+
+	// the tree groups files by extension, then directory
+	tree := make(map[string]map[string][]testFile)
+
+	for _, file := range files {
+		dir, name := path.Split(file.path)
+		dir = strings.TrimSuffix(dir, "/")
+		ext := path.Ext(name)
+		name = strings.TrimSuffix(name, ext)
+		ext = strings.TrimPrefix(ext, ".")
+
+		if dir == "" {
+			dir = " "
+		}
+
+		if ext == "" {
+			ext = " "
+		}
+
+		if tree[ext] == nil {
+			tree[ext] = make(map[string][]testFile)
+		}
+
+		file.path = name
+		tree[ext][dir] = append(tree[ext][dir], file)
+	}
+
+	var treeData, dirData bytes.Buffer
+	archives := make(map[uint16]*bytes.Buffer)
+
+	for ext, dirs := range tree {
+		treeData.WriteString(ext + "\x00")
+
+		for dir, files := range dirs {
+			treeData.WriteString(dir + "\x00")
+
+			for _, file := range files {
+				crc := crc32.ChecksumIEEE([]byte(file.data))
+				if file.corrupt {
+					crc ^= 1
+				}
+
+				data := &dirData
+				if file.archive != dirArchiveIndex {
+					if archives[file.archive] == nil {
+						archives[file.archive] = &bytes.Buffer{}
+					}
+
+					data = archives[file.archive]
+				}
+
+				treeData.WriteString(file.path + "\x00")
+				binary.Write(&treeData, binary.LittleEndian, struct {
+					CRC          uint32
+					PreloadBytes uint16
+					ArchiveIndex uint16
+					Offset       uint32
+					Length       uint32
+					Terminator   uint16
+				}{crc, uint16(file.preload), file.archive, uint32(data.Len()), uint32(len(file.data) - file.preload), entryTerminator})
+				treeData.WriteString(file.data[:file.preload])
+
+				data.WriteString(file.data[file.preload:])
+			}
+
+			treeData.WriteString("\x00")
+		}
+
+		treeData.WriteString("\x00")
+	}
+
+	treeData.WriteString("\x00")
+
+	var out bytes.Buffer
+
+	binary.Write(&out, binary.LittleEndian, []uint32{signature, version, uint32(treeData.Len())})
+
+	// the remainder of the v2 header (file data, archive MD5, other MD5 and
+	// signature section sizes)
+	if version == 2 {
+		binary.Write(&out, binary.LittleEndian, []uint32{uint32(dirData.Len()), 0, 0, 0})
+	}
+
+	out.Write(treeData.Bytes())
+	out.Write(dirData.Bytes())
+
+	prefix := filepath.Join(dir, fmt.Sprintf("pak_v%d", version))
+
+	if err := os.WriteFile(prefix+"_dir.vpk", out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for index, data := range archives {
+		if err := os.WriteFile(fmt.Sprintf("%s_%03d.vpk", prefix, index), data.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return prefix + "_dir.vpk"
+}
+
+// openPackage writes and opens a package of the provided version.
+func openPackage(t *testing.T, version uint32, files []testFile) *Archive {
+
+	t.Helper()
+
+	archive, err := Open(writePackage(t, t.TempDir(), version, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		archive.Close()
+	})
+
+	return archive
+}
+
+func TestArchive(t *testing.T) {
+
+	for _, version := range []uint32{1, 2} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+
+			archive := openPackage(t, version, testFiles)
+
+			if archive.Version != version {
+				t.Errorf("Version = %d, expected %d", archive.Version, version)
+			}
+
+			var expected []string
+			for _, file := range testFiles {
+				expected = append(expected, file.path)
+			}
+
+			sort.Strings(expected)
+
+			var paths []string
+			for _, entry := range archive.Entries() {
+				paths = append(paths, entry.Path)
+			}
+
+			if strings.Join(paths, ",") != strings.Join(expected, ",") {
+				t.Errorf("Entries = %v, expected %v", paths, expected)
+			}
+
+			for _, file := range testFiles {
+				data, err := archive.ReadFile(file.path)
+				if err != nil {
+					t.Errorf("ReadFile(%s): %v", file.path, err)
+					continue
+				}
+
+				if string(data) != file.data {
+					t.Errorf("ReadFile(%s) = %q, expected %q", file.path, data, file.data)
+				}
+			}
+
+			if err := archive.Verify(); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+
+			if err := fstest.TestFS(archive, expected...); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestEntryFold(t *testing.T) {
+
+	archive := openPackage(t, 2, testFiles)
+
+	entry, ok := archive.Entry("Scripts/Items/Items_Game.txt")
+	if !ok || entry.Path != "scripts/items/items_game.txt" {
+		t.Errorf("Entry = %v, %v, expected scripts/items/items_game.txt", entry, ok)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+
+	files := append([]testFile{}, testFiles...)
+	files[0].corrupt = true
+
+	for _, version := range []uint32{1, 2} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+
+			archive := openPackage(t, version, files)
+
+			if _, err := fs.ReadFile(archive, files[0].path); !errors.Is(err, ErrChecksum) {
+				t.Errorf("ReadFile error = %v, expected ErrChecksum", err)
+			}
+
+			if err := archive.Verify(); !errors.Is(err, ErrChecksum) {
+				t.Errorf("Verify error = %v, expected ErrChecksum", err)
+			}
+
+			// other files are unaffected
+			if _, err := archive.ReadFile(files[1].path); err != nil {
+				t.Errorf("ReadFile(%s): %v", files[1].path, err)
+			}
+		})
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+
+	dir := t.TempDir()
+
+	for name, data := range map[string][]byte{
+		"signature": {0x34, 0x12, 0xAA, 0x00, 1, 0, 0, 0, 0, 0, 0, 0},
+		"version":   {0x34, 0x12, 0xAA, 0x55, 3, 0, 0, 0, 0, 0, 0, 0},
+		"truncated": {0x34, 0x12, 0xAA, 0x55, 1, 0, 0, 0, 10, 0, 0, 0, 'a'},
+	} {
+		location := filepath.Join(dir, name+"_dir.vpk")
+
+		if err := os.WriteFile(location, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if archive, err := Open(location); err == nil {
+			archive.Close()
+			t.Errorf("Open(%s) succeeded, expected error", name)
+		}
+	}
+}